@@ -1,14 +1,23 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"searchav/internal/auth"
+	"searchav/internal/cache"
 	"searchav/internal/config"
 	"searchav/internal/handler"
+	"searchav/internal/metrics"
 	"searchav/internal/service"
 	"searchav/internal/source"
+	"searchav/internal/store"
+	"searchav/internal/tracing"
 
 	_ "searchav/docs"
 
@@ -16,6 +25,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 )
@@ -26,6 +36,14 @@ import (
 // @host localhost:9898
 // @BasePath /api
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		if err := hashPasswordCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fx.New(
 		// Configuration
 		fx.Provide(config.New),
@@ -33,23 +51,47 @@ func main() {
 		// Logger
 		fx.Provide(NewLogger),
 
+		// Config hot reload
+		fx.Provide(config.NewWatcher),
+
+		// Metrics
+		fx.Provide(metrics.New),
+
 		// Source client
-		fx.Provide(source.NewClient),
+		fx.Provide(fx.Annotate(source.NewClient, fx.As(fx.Self()), fx.As(new(source.Fetcher)))),
+		fx.Provide(source.NewCachedProvider),
+
+		// Source store
+		fx.Provide(store.NewSourceStore),
 
 		// Service layer
+		fx.Provide(service.NewAuthService),
+		fx.Provide(service.NewAuthorizer),
+		fx.Provide(service.NewPlayHosts),
 		fx.Provide(service.NewSearchService),
 		fx.Provide(service.NewDetailService),
+		fx.Provide(service.NewHLSService),
+		fx.Provide(service.NewSourceService),
+		fx.Provide(service.NewAdminService),
 
 		// Handlers
 		fx.Provide(handler.NewContextHandler),
+		fx.Provide(handler.NewAuthHandler),
 		fx.Provide(handler.NewSearchHandler),
 		fx.Provide(handler.NewDetailHandler),
+		fx.Provide(handler.NewHLSHandler),
+		fx.Provide(handler.NewSourceHandler),
+		fx.Provide(handler.NewAdminHandler),
+		fx.Provide(handler.NewMetricsHandler),
 
 		// Fiber App
 		fx.Provide(NewFiberApp),
 
 		// Start
+		fx.Invoke(StartTracing),
+		fx.Invoke(RegisterMetrics),
 		fx.Invoke(RegisterRoutes),
+		fx.Invoke(StartConfigWatcher),
 		fx.Invoke(StartServer),
 	).Run()
 }
@@ -66,32 +108,51 @@ func NewLogger(cfg *config.Config) *zerolog.Logger {
 			With().Timestamp().Logger()
 	}
 
+	// The global level can be changed by config reloads
 	level, _ := zerolog.ParseLevel(cfg.Log.Level)
-	logger = logger.Level(level)
+	zerolog.SetGlobalLevel(level)
 
 	return &logger
 }
 
 // NewFiberApp creates a Fiber application
-func NewFiberApp() *fiber.App {
+func NewFiberApp(cfg *config.Config, m *metrics.Metrics, logger *zerolog.Logger) (*fiber.App, error) {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: handler.ErrorHandler,
 	})
 
+	// Client IPs are taken from the proxy header only behind trusted proxies
+	clientIP, err := handler.ClientIPMiddleware(cfg.Server.ProxyHeader, cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("server.trusted_proxies: %w", err)
+	}
+
 	// Middleware
+	app.Use(clientIP)
+	app.Use(handler.RequestIDMiddleware())
+	app.Use(handler.TracingMiddleware())
+	app.Use(handler.AccessLogMiddleware(logger))
+	app.Use(handler.MetricsMiddleware(m))
 	app.Use(recover.New())
 	app.Use(cors.New())
 
-	return app
+	return app, nil
 }
 
 // RegisterRoutes registers all routes
 func RegisterRoutes(
 	app *fiber.App,
 	cfg *config.Config,
+	authService *service.AuthService,
+	authorizer *service.Authorizer,
 	ctxHandler *handler.ContextHandler,
+	authHandler *handler.AuthHandler,
 	searchHandler *handler.SearchHandler,
 	detailHandler *handler.DetailHandler,
+	hlsHandler *handler.HLSHandler,
+	sourceHandler *handler.SourceHandler,
+	adminHandler *handler.AdminHandler,
+	metricsHandler *handler.MetricsHandler,
 ) {
 	// Health check
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -101,10 +162,103 @@ func RegisterRoutes(
 	// Swagger docs
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	// API routes with auth middleware
-	api := app.Group("/api", handler.AuthMiddleware(cfg))
+	// Prometheus metrics, protected by their own token
+	if cfg.Metrics.Enabled {
+		app.Get("/metrics", handler.MetricsAuthMiddleware(cfg.Metrics.Token), metricsHandler.Metrics)
+	}
+
+	// Login, exchanges a password for a session token
+	app.Post("/api/auth/login", ctxHandler.Wrap(authHandler.Login))
+
+	// API routes with auth middleware, sources named in queries are authorized centrally
+	api := app.Group("/api", handler.AuthMiddleware(authService), handler.AuthorizeMiddleware(authorizer))
 	api.Get("/search", ctxHandler.Wrap(searchHandler.Search))
+	api.Get("/search/stream", ctxHandler.Wrap(searchHandler.SearchStream))
 	api.Get("/detail", ctxHandler.Wrap(detailHandler.GetDetail))
+	api.Get("/hls/playlist", ctxHandler.Wrap(hlsHandler.Playlist))
+	api.Get("/sources", ctxHandler.Wrap(sourceHandler.List))
+	api.Get("/sources/health", ctxHandler.Wrap(sourceHandler.Health))
+
+	// Admin routes, require an admin password
+	admin := api.Group("/admin", handler.AdminMiddleware())
+	admin.Get("/sources", ctxHandler.Wrap(adminHandler.ListSources))
+	admin.Post("/sources", ctxHandler.Wrap(adminHandler.CreateSource))
+	admin.Put("/sources/:code", ctxHandler.Wrap(adminHandler.UpdateSource))
+	admin.Delete("/sources/:code", ctxHandler.Wrap(adminHandler.DeleteSource))
+}
+
+// RegisterMetrics exposes the counters kept by services and the response
+// cache as metrics
+func RegisterMetrics(m *metrics.Metrics, provider source.Provider, searchService *service.SearchService, authService *service.AuthService) {
+	m.CounterFunc("searches_total", "Aggregated searches, including coalesced ones.", nil, func() float64 {
+		return float64(searchService.Stats().Searches)
+	})
+	m.CounterFunc("searches_coalesced_total", "Searches that joined an identical in-flight search.", nil, func() float64 {
+		return float64(searchService.Stats().Coalesced)
+	})
+	m.GaugeFunc("pinyin_index_titles", "Titles in the pinyin search index.", nil, func() float64 {
+		return float64(searchService.PinyinTitles())
+	})
+	m.CounterFunc("auth_lockouts_total", "Clients locked out after failed password attempts.", nil, func() float64 {
+		return float64(authService.LockoutStats().Lockouts)
+	})
+
+	cached, ok := provider.(*source.CachedProvider)
+	if !ok {
+		return
+	}
+	for name, stats := range map[string]func() cache.Stats{
+		"search": cached.SearchStats,
+		"detail": cached.DetailStats,
+	} {
+		labels := prometheus.Labels{"cache": name}
+		m.CounterFunc("cache_hits_total", "Response cache hits.", labels, func() float64 {
+			return float64(stats().Hits)
+		})
+		m.CounterFunc("cache_misses_total", "Response cache misses.", labels, func() float64 {
+			return float64(stats().Misses)
+		})
+		m.GaugeFunc("cache_entries", "Entries in the response cache.", labels, func() float64 {
+			return float64(stats().Entries)
+		})
+	}
+}
+
+// StartTracing installs the OpenTelemetry tracer provider and flushes pending
+// spans on shutdown
+func StartTracing(lc fx.Lifecycle, cfg *config.Config, logger *zerolog.Logger) error {
+	shutdown, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+	if cfg.Tracing.Enabled {
+		logger.Info().
+			Str("endpoint", cfg.Tracing.Endpoint).
+			Float64("sample_ratio", cfg.Tracing.SampleRatio).
+			Msg("tracing enabled")
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// StartConfigWatcher reloads configuration on file changes and SIGHUP.
+// Sources changed by a reload are forgotten like sources changed by admins.
+func StartConfigWatcher(lc fx.Lifecycle, watcher *config.Watcher, admin *service.AdminService) {
+	watcher.OnSourceChange(admin.Forget)
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return watcher.Start()
+		},
+		OnStop: func(ctx context.Context) error {
+			watcher.Stop()
+			return nil
+		},
+	})
 }
 
 // StartServer starts the HTTP server
@@ -113,7 +267,7 @@ func StartServer(lc fx.Lifecycle, app *fiber.App, cfg *config.Config, logger *ze
 	logger.Info().
 		Bool("auth_enabled", cfg.Auth.Enabled).
 		Int("passwords_count", len(cfg.Auth.Passwords)).
-		Int("sources_count", len(cfg.Sources)).
+		Int("sources_count", len(cfg.GetSources())).
 		Msg("config loaded")
 
 	lc.Append(fx.Hook{
@@ -133,3 +287,37 @@ func StartServer(lc fx.Lifecycle, app *fiber.App, cfg *config.Config, logger *ze
 		},
 	})
 }
+
+// hashPasswordCommand prints the hash of a password for use in auth.passwords.
+// The password is read from standard input when not given as an argument.
+//
+//	server hash-password [-algo bcrypt|argon2id] [password]
+func hashPasswordCommand(args []string) error {
+	fs := flag.NewFlagSet("hash-password", flag.ExitOnError)
+	algo := fs.String("algo", auth.AlgoBcrypt, "hash algorithm: bcrypt or argon2id")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: server hash-password [-algo bcrypt|argon2id] [password]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	password := fs.Arg(0)
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return errors.New("password is empty")
+	}
+
+	hash, err := auth.HashPassword(password, *algo)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
+}
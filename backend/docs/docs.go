@@ -15,6 +15,219 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/sources": {
+            "get": {
+                "description": "List every source including disabled ones. Requires an admin password.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List all sources (admin)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.AdminSourcesResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Validate a source with a test query, persist it and start using it without a restart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create a source (admin)",
+                "parameters": [
+                    {
+                        "description": "Source",
+                        "name": "source",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_config.SourceItem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sources/{code}": {
+            "put": {
+                "description": "Validate a source with a test query, persist it and start using it without a restart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update a source (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Source",
+                        "name": "source",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_config.SourceItem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a source (admin)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.SuccessResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Exchange a password for a signed, expiring session token carrying its permissions.\nSend the token as \"Authorization: Bearer \u003ctoken\u003e\" instead of X-Auth-Password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/detail": {
             "get": {
                 "description": "Get video details and play URLs from a specific source",
@@ -57,11 +270,113 @@ const docTemplate = `{
                             "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/hls/playlist": {
+            "get": {
+                "description": "Fetch an m3u8 playlist of a source, resolve master playlists, rewrite relative URIs to absolute and remove ad segments. The playlist must be on the source's API host or on a host of the play URLs it returned.",
+                "produces": [
+                    "application/vnd.apple.mpegurl"
+                ],
+                "tags": [
+                    "hls"
+                ],
+                "summary": "Get ad-filtered HLS playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source code the playlist belongs to",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playlist URL",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "m3u8 playlist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -92,19 +407,166 @@ const docTemplate = `{
                         "description": "Include adult sources (1=yes, 0=no, default=0)",
                         "name": "adult",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated source codes to search, default all",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results of this kind (movie, series, anime, variety, documentary) or category name",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results released in this year, e.g. 2023",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results from this area, e.g. 大陆",
+                        "name": "area",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/stream": {
+            "get": {
+                "description": "Aggregate search across multiple video sources, pushing Server-Sent Events as each source responds.\nEvents: \"source\" (per-source status), \"results\" (merged and ranked list so far, matching the filter, batched over 100ms),\n\"done\" (summary with facet counts).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search videos (streamed)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search keyword",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Include adult sources (1=yes, 0=no, default=0)",
+                        "name": "adult",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated source codes to search, default all",
+                        "name": "sources",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results of this kind (movie, series, anime, variety, documentary) or category name",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results released in this year, e.g. 2023",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only results from this area, e.g. 大陆",
+                        "name": "area",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sources": {
+            "get": {
+                "description": "List configured sources with their enabled state and live health status.\nOnly sources the caller's profile may use are listed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "List sources",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/searchav_internal_dto.SourcesResponse"
+                        }
                     }
+                }
+            }
+        },
+        "/sources/health": {
+            "get": {
+                "description": "Get the health and circuit breaker state of each configured source",
+                "consumes": [
+                    "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Get source health",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/searchav_internal_dto.SearchResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/searchav_internal_dto.ErrorResponse"
+                            "$ref": "#/definitions/searchav_internal_dto.SourceHealthResponse"
                         }
                     }
                 }
@@ -112,6 +574,49 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "searchav_internal_config.SourceItem": {
+            "type": "object",
+            "properties": {
+                "adult": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Source protocol type, defaults to maccms_json",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_dto.AdminSourcesResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_config.SourceItem"
+                    }
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
         "searchav_internal_dto.DetailResponse": {
             "type": "object",
             "properties": {
@@ -133,11 +638,39 @@ const docTemplate = `{
             "properties": {
                 "code": {
                     "type": "integer",
-                    "example": 400
+                    "example": 404
+                },
+                "error": {
+                    "type": "string",
+                    "example": "source_not_found"
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "source not found: abc"
+                }
+            }
+        },
+        "searchav_internal_dto.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_dto.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "data": {
+                    "$ref": "#/definitions/searchav_internal_model.LoginResult"
                 },
                 "msg": {
                     "type": "string",
-                    "example": "bad request"
+                    "example": "success"
                 }
             }
         },
@@ -148,6 +681,9 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 200
                 },
+                "facets": {
+                    "$ref": "#/definitions/searchav_internal_model.SearchFacets"
+                },
                 "list": {
                     "type": "array",
                     "items": {
@@ -157,6 +693,145 @@ const docTemplate = `{
                 "msg": {
                     "type": "string",
                     "example": "success"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.SourceStatus"
+                    }
+                }
+            }
+        },
+        "searchav_internal_dto.SourceHealthResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_source.Health"
+                    }
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "searchav_internal_dto.SourcesResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.SourceListItem"
+                    }
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "searchav_internal_dto.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "searchav_internal_model.Episode": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.FacetCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.LoginResult": {
+            "type": "object",
+            "properties": {
+                "admin": {
+                    "type": "boolean"
+                },
+                "adult": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.PlayLine": {
+            "type": "object",
+            "properties": {
+                "episodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.Episode"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.SearchFacets": {
+            "type": "object",
+            "properties": {
+                "area": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.FacetCount"
+                    }
+                },
+                "type": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.FacetCount"
+                    }
+                },
+                "year": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.FacetCount"
+                    }
                 }
             }
         },
@@ -169,8 +844,62 @@ const docTemplate = `{
                 "source_name": {
                     "type": "string"
                 },
+                "variant": {
+                    "description": "Variant label such as \"国语\" or \"第2季\", empty for the plain title",
+                    "type": "string"
+                },
                 "vod_id": {
                     "type": "integer"
+                },
+                "vod_name": {
+                    "description": "Title as listed by the source",
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.SourceListItem": {
+            "type": "object",
+            "properties": {
+                "adult": {
+                    "type": "boolean"
+                },
+                "avg_latency_ms": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_model.SourceStatus": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "source_code": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
@@ -178,11 +907,18 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "episodes": {
+                    "description": "Episodes is the flat URL list of the preferred line.\nDeprecated: kept for compatibility with older clients, use Lines.",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/searchav_internal_model.PlayLine"
+                    }
+                },
                 "vod_actor": {
                     "type": "string"
                 },
@@ -209,6 +945,10 @@ const docTemplate = `{
         "searchav_internal_model.VideoItem": {
             "type": "object",
             "properties": {
+                "kind": {
+                    "description": "Coarse kind: movie, series, anime, variety or documentary",
+                    "type": "string"
+                },
                 "sources": {
                     "type": "array",
                     "items": {
@@ -218,6 +958,9 @@ const docTemplate = `{
                 "type_name": {
                     "type": "string"
                 },
+                "vod_area": {
+                    "type": "string"
+                },
                 "vod_name": {
                     "type": "string"
                 },
@@ -226,6 +969,42 @@ const docTemplate = `{
                 },
                 "vod_remarks": {
                     "type": "string"
+                },
+                "vod_year": {
+                    "description": "Release year such as \"2023\"",
+                    "type": "string"
+                }
+            }
+        },
+        "searchav_internal_source.Health": {
+            "type": "object",
+            "properties": {
+                "avg_latency_ms": {
+                    "type": "integer"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_error_at": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "retry_at": {
+                    "type": "string"
+                },
+                "source_code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
                 }
             }
         }
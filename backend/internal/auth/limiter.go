@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter tracks failed password attempts per client and locks a client out
+// with exponential backoff once it exceeds the allowed attempts.
+// A nil Limiter never locks anyone out.
+type Limiter struct {
+	mu       sync.Mutex
+	attempts map[string]*attempts
+	lockouts uint64
+
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	resetAfter  time.Duration
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+// attempts is the failure history of a single client
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LimiterStats reports lockout counters
+type LimiterStats struct {
+	Lockouts uint64 // Lockouts triggered since start
+	Tracked  int    // Clients with recent failures
+}
+
+// NewLimiter creates a limiter allowing maxAttempts failures before locking a
+// client out: the next failure locks it out for baseDelay, doubling with every
+// further failure up to maxDelay.
+// A client's history is forgotten resetAfter its last failure.
+func NewLimiter(maxAttempts int, baseDelay, maxDelay, resetAfter time.Duration) *Limiter {
+	return &Limiter{
+		attempts:    make(map[string]*attempts),
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		resetAfter:  resetAfter,
+		now:         time.Now,
+	}
+}
+
+// RetryAfter returns how long the client is still locked out, 0 if it is not
+func (l *Limiter) RetryAfter(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[key]
+	if !ok {
+		return 0
+	}
+	if wait := a.lockedUntil.Sub(l.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt and returns the lockout it triggers, 0 if none
+func (l *Limiter) Fail(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	a, ok := l.attempts[key]
+	if !ok || now.Sub(a.lastFailure) > l.resetAfter {
+		a = &attempts{}
+		l.attempts[key] = a
+	}
+	a.failures++
+	a.lastFailure = now
+
+	excess := a.failures - l.maxAttempts - 1
+	if excess < 0 {
+		return 0
+	}
+
+	delay := l.maxDelay
+	if excess < 32 {
+		if d := l.baseDelay << excess; d > 0 && d < l.maxDelay {
+			delay = d
+		}
+	}
+	a.lockedUntil = now.Add(delay)
+	l.lockouts++
+	return delay
+}
+
+// Reset forgets the failures of a client after a successful attempt
+func (l *Limiter) Reset(key string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
+
+// Stats returns lockout counters
+func (l *Limiter) Stats() LimiterStats {
+	if l == nil {
+		return LimiterStats{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LimiterStats{Lockouts: l.lockouts, Tracked: len(l.attempts)}
+}
+
+// sweep drops clients whose history has expired, at most once per resetAfter
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.resetAfter {
+		return
+	}
+	l.lastSweep = now
+
+	for key, a := range l.attempts {
+		if now.Sub(a.lastFailure) > l.resetAfter && now.After(a.lockedUntil) {
+			delete(l.attempts, key)
+		}
+	}
+}
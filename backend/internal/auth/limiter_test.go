@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter creates a limiter on a clock the test moves
+func newTestLimiter(maxAttempts int, baseDelay, maxDelay, resetAfter time.Duration) (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLimiter(maxAttempts, baseDelay, maxDelay, resetAfter)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     []time.Duration // Lockout returned by every failure
+	}{
+		{
+			name:     "within max attempts",
+			failures: 3,
+			want:     []time.Duration{0, 0, 0},
+		},
+		{
+			name:     "threshold",
+			failures: 4,
+			want:     []time.Duration{0, 0, 0, time.Second},
+		},
+		{
+			name:     "doubling delay",
+			failures: 6,
+			want:     []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "max delay cap",
+			failures: 9,
+			want:     []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(3, time.Second, 10*time.Second, time.Hour)
+			for i := 0; i < tt.failures; i++ {
+				if got := l.Fail("client"); got != tt.want[i] {
+					t.Errorf("failure %d: lockout = %s, want %s", i+1, got, tt.want[i])
+				}
+			}
+			if got, want := l.RetryAfter("client"), tt.want[tt.failures-1]; got != want {
+				t.Errorf("RetryAfter = %s, want %s", got, want)
+			}
+			if got := l.RetryAfter("other"); got != 0 {
+				t.Errorf("RetryAfter(other) = %s, want 0", got)
+			}
+		})
+	}
+}
+
+func TestLimiterLockoutExpires(t *testing.T) {
+	l, now := newTestLimiter(1, time.Minute, time.Hour, time.Hour)
+	l.Fail("client")
+	if got := l.Fail("client"); got != time.Minute {
+		t.Fatalf("lockout = %s, want 1m", got)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if got := l.RetryAfter("client"); got != 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 30s", got)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if got := l.RetryAfter("client"); got != 0 {
+		t.Errorf("RetryAfter after the lockout = %s, want 0", got)
+	}
+}
+
+func TestLimiterResetAfter(t *testing.T) {
+	l, now := newTestLimiter(2, time.Second, time.Minute, time.Hour)
+	l.Fail("client")
+	l.Fail("client")
+
+	// A failure resetAfter the last one starts a new history
+	*now = now.Add(time.Hour + time.Second)
+	if got := l.Fail("client"); got != 0 {
+		t.Errorf("lockout after reset_after = %s, want 0", got)
+	}
+	if got := l.Fail("client"); got != 0 {
+		t.Errorf("second failure after reset_after = %s, want 0", got)
+	}
+	if got := l.Fail("client"); got != time.Second {
+		t.Errorf("third failure after reset_after = %s, want 1s", got)
+	}
+
+	// Expired clients are swept
+	*now = now.Add(2 * time.Hour)
+	l.Fail("other")
+	if got := l.Stats().Tracked; got != 1 {
+		t.Errorf("Tracked = %d, want 1", got)
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	l, _ := newTestLimiter(1, time.Second, time.Minute, time.Hour)
+	l.Fail("client")
+	l.Reset("client")
+	if got := l.Fail("client"); got != 0 {
+		t.Errorf("lockout after Reset = %s, want 0", got)
+	}
+
+	stats := l.Stats()
+	if stats.Lockouts != 0 || stats.Tracked != 1 {
+		t.Errorf("Stats = %+v, want no lockouts and 1 tracked", stats)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	if got := l.Fail("client"); got != 0 {
+		t.Errorf("Fail = %s, want 0", got)
+	}
+	if got := l.RetryAfter("client"); got != 0 {
+		t.Errorf("RetryAfter = %s, want 0", got)
+	}
+}
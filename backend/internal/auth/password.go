@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// AlgoBcrypt hashes passwords with bcrypt
+	AlgoBcrypt = "bcrypt"
+	// AlgoArgon2 hashes passwords with argon2id
+	AlgoArgon2 = "argon2id"
+)
+
+// argon2id parameters used for new hashes, stored hashes carry their own
+const (
+	argon2Memory  = 64 * 1024
+	argon2Time    = 3
+	argon2Threads = 2
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// ErrUnknownAlgo is returned when hashing with an unsupported algorithm
+var ErrUnknownAlgo = errors.New("unknown hash algorithm")
+
+// HashPassword hashes a password with the given algorithm
+func HashPassword(password, algo string) (string, error) {
+	switch algo {
+	case AlgoBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	case AlgoArgon2:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownAlgo, algo)
+	}
+}
+
+// IsHashed reports whether a configured password is a bcrypt or argon2id hash
+func IsHashed(stored string) bool {
+	return isBcrypt(stored) || strings.HasPrefix(stored, "$argon2id$")
+}
+
+// VerifyPassword checks a password against a configured entry.
+// The entry is either a bcrypt hash, an argon2id hash in PHC format or a
+// plaintext password.
+func VerifyPassword(stored, password string) bool {
+	switch {
+	case isBcrypt(stored):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case strings.HasPrefix(stored, "$argon2id$"):
+		return verifyArgon2(stored, password)
+	default:
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+	}
+}
+
+func isBcrypt(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") ||
+		strings.HasPrefix(stored, "$2b$") ||
+		strings.HasPrefix(stored, "$2y$")
+}
+
+// verifyArgon2 checks a password against $argon2id$v=19$m=...,t=...,p=...$salt$key
+func verifyArgon2(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, actual) == 1
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a size-bounded in-memory cache with per-entry TTL.
+// The least recently used entry is evicted when the cache is full.
+type Cache[K comparable, V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[K]*list.Element
+	hits       uint64
+	misses     uint64
+	now        func() time.Time
+}
+
+// Stats are cache usage counters
+type Stats struct {
+	Entries int
+	Hits    uint64
+	Misses  uint64
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// New creates a cache holding at most maxEntries entries (0 = unbounded)
+func New[K comparable, V any](maxEntries int) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[K]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if c.now().Before(e.expires) {
+			c.ll.MoveToFront(el)
+			c.hits++
+			return e.value, true
+		}
+		c.removeElement(el)
+	}
+
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Set stores a value for key with the given TTL
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value = value
+		e.expires = expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// DeleteFunc removes every key for which del returns true and returns the
+// number of removed entries
+func (c *Cache[K, V]) DeleteFunc(del func(K) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, el := range c.items {
+		if del(key) {
+			c.removeElement(el)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Stats returns the cache usage counters
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Entries: c.ll.Len(),
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+}
+
+func (c *Cache[K, V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
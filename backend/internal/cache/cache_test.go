@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeleteFunc(t *testing.T) {
+	c := New[string, int](0)
+	for i, key := range []string{"a\x00x", "a\x00y", "b\x00x", "ab\x00x"} {
+		c.Set(key, i, time.Minute)
+	}
+
+	removed := c.DeleteFunc(func(key string) bool { return strings.HasPrefix(key, "a\x00") })
+	if removed != 2 {
+		t.Errorf("DeleteFunc removed %d entries, want 2", removed)
+	}
+	for key, want := range map[string]bool{"a\x00x": false, "a\x00y": false, "b\x00x": true, "ab\x00x": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+}
@@ -5,38 +5,86 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/spf13/viper"
 )
 
+// ConfigDir is the directory holding config.yaml and config.local.yaml
+const ConfigDir = "./configs"
+
+// minTokenSecretLen is the shortest accepted auth.token_secret
+const minTokenSecretLen = 32
+
 type Config struct {
-	Server  ServerConfig `mapstructure:"server"`
-	Log     LogConfig    `mapstructure:"log"`
-	Auth    AuthConfig   `mapstructure:"auth"`
-	Source  SourceConfig `mapstructure:"source"`
-	Sources []SourceItem `mapstructure:"sources"`
+	Server  ServerConfig  `mapstructure:"server"`
+	Log     LogConfig     `mapstructure:"log"`
+	Auth    AuthConfig    `mapstructure:"auth"`
+	Source  SourceConfig  `mapstructure:"source"`
+	Sources []SourceItem  `mapstructure:"sources"`
+	Search  SearchConfig  `mapstructure:"search"`
+	HLS     HLSConfig     `mapstructure:"hls"`
+	Cache   CacheConfig   `mapstructure:"cache"`
+	Admin   AdminConfig   `mapstructure:"admin"`
+	Metrics MetricsConfig `mapstructure:"metrics"`
+	Tracing TracingConfig `mapstructure:"tracing"`
+
+	// mu guards the settings that can be changed at runtime:
+	// Sources, Auth, Source.Timeout and Log.Level
+	mu sync.RWMutex
+	// sourcesManaged is set once sources are managed through the admin API,
+	// file reloads then leave them alone
+	sourcesManaged bool
 }
 
 type AuthConfig struct {
-	Enabled   bool           `mapstructure:"enabled"`
-	Passwords []PasswordItem `mapstructure:"passwords"`
+	Enabled     bool           `mapstructure:"enabled"`
+	Passwords   []PasswordItem `mapstructure:"passwords"`
+	TokenSecret string         `mapstructure:"token_secret"` // Signs login tokens, random per start when empty
+	TokenTTL    time.Duration  `mapstructure:"token_ttl"`    // Lifetime of login tokens
+	Lockout     LockoutConfig  `mapstructure:"lockout"`
 }
 
+// LockoutConfig limits password guessing per client IP
+type LockoutConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	MaxAttempts int           `mapstructure:"max_attempts"` // Failed attempts allowed before the first lockout
+	BaseDelay   time.Duration `mapstructure:"base_delay"`   // First lockout, doubled with every further failure
+	MaxDelay    time.Duration `mapstructure:"max_delay"`    // Longest lockout
+	ResetAfter  time.Duration `mapstructure:"reset_after"`  // Failures are forgotten this long after the last one
+}
+
+// PasswordItem is a credential and the profile it grants
 type PasswordItem struct {
-	Password string `mapstructure:"password"`
-	Adult    bool   `mapstructure:"adult"`
+	Name         string   `mapstructure:"name"`     // Profile name, required for access rules and expiry
+	Password     string   `mapstructure:"password"` // Plaintext, bcrypt or argon2id hash
+	Adult        bool     `mapstructure:"adult"`
+	Admin        bool     `mapstructure:"admin"`         // Can manage sources through the admin API
+	AllowSources []string `mapstructure:"allow_sources"` // Only these source codes, empty allows all
+	DenySources  []string `mapstructure:"deny_sources"`  // Never these source codes
+	Categories   []string `mapstructure:"categories"`    // Allowed type_name values, empty allows all
+	Expires      string   `mapstructure:"expires"`       // Date (2006-01-02) or RFC 3339 time
+}
+
+// Credential is a password entry as checked at login
+type Credential struct {
+	Password string // Plaintext, bcrypt or argon2id hash
+	Profile
 }
 
 // AuthResult contains the result of password validation
 type AuthResult struct {
 	Valid bool
-	Adult bool
+	Profile
 }
 
 type ServerConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
+	Host           string   `mapstructure:"host"`
+	Port           int      `mapstructure:"port"`
+	ProxyHeader    string   `mapstructure:"proxy_header"`    // Header carrying the client IP, e.g. X-Forwarded-For
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // Proxy IPs or CIDRs allowed to set ProxyHeader
 }
 
 type LogConfig struct {
@@ -47,19 +95,94 @@ type LogConfig struct {
 type SourceConfig struct {
 	Timeout time.Duration `mapstructure:"timeout"`
 	Retry   int           `mapstructure:"retry"`
+	Breaker BreakerConfig `mapstructure:"breaker"`
 }
 
-type SourceItem struct {
-	Code    string `mapstructure:"code"`
-	Name    string `mapstructure:"name"`
-	URL     string `mapstructure:"url"`
+type BreakerConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FailureThreshold int           `mapstructure:"failure_threshold"` // Consecutive failures before the circuit opens
+	Cooldown         time.Duration `mapstructure:"cooldown"`          // How long an open circuit skips the source
+}
+
+type SearchConfig struct {
+	Pinyin  PinyinConfig  `mapstructure:"pinyin"`
+	Ranking RankingConfig `mapstructure:"ranking"`
+}
+
+type PinyinConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	MaxTitles     int  `mapstructure:"max_titles"`     // Titles kept in the pinyin index
+	MaxCandidates int  `mapstructure:"max_candidates"` // Titles searched for per pinyin query
+}
+
+type RankingConfig struct {
+	Scorer        string             `mapstructure:"scorer"` // weighted or exact
+	Weights       RankingWeights     `mapstructure:"weights"`
+	SourceWeights map[string]float64 `mapstructure:"source_weights"` // Quality weight in [0, 1] by source code, 1 when unset
+}
+
+// RankingWeights weighs the signals of the weighted scorer, all zero uses the
+// default weights
+type RankingWeights struct {
+	Similarity float64 `mapstructure:"similarity"` // Edit distance between keyword and title
+	Tokens     float64 `mapstructure:"tokens"`     // Share of keyword words found in the title
+	Sources    float64 `mapstructure:"sources"`    // Share of searched sources listing the title
+	Year       float64 `mapstructure:"year"`       // Closeness to the year in the keyword, or to the current year
+	Quality    float64 `mapstructure:"quality"`    // Best quality weight of the listing sources
+}
+
+type CacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	SearchTTL  time.Duration `mapstructure:"search_ttl"`
+	DetailTTL  time.Duration `mapstructure:"detail_ttl"`
+	ErrorTTL   time.Duration `mapstructure:"error_ttl"` // Negative caching of failed requests, 0 disables
+	MaxEntries int           `mapstructure:"max_entries"`
+}
+
+type HLSConfig struct {
+	MaxAdRunDuration     time.Duration `mapstructure:"max_ad_run_duration"`    // Longest discontinuity run treated as an ad
+	AllowedHosts         []string      `mapstructure:"allowed_hosts"`          // Playlist hosts and their subdomains, empty allows any public host
+	AllowPrivateNetworks bool          `mapstructure:"allow_private_networks"` // Allow playlists on loopback, private and link-local addresses
+}
+
+type MetricsConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
-	Adult   bool   `mapstructure:"adult"`
+	Token   string `mapstructure:"token"` // Bearer token required to scrape /metrics
+}
+
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"` // OTLP/HTTP collector address, e.g. localhost:4318
+	Insecure    bool    `mapstructure:"insecure"` // Use plain HTTP instead of HTTPS
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
+}
+
+type AdminConfig struct {
+	SourcesStore string `mapstructure:"sources_store"` // File persisting sources managed through the admin API
+}
+
+type SourceItem struct {
+	Code    string `mapstructure:"code" json:"code"`
+	Name    string `mapstructure:"name" json:"name"`
+	URL     string `mapstructure:"url" json:"url"`
+	Type    string `mapstructure:"type" json:"type,omitempty"` // Source protocol type, defaults to maccms_json
+	Enabled bool   `mapstructure:"enabled" json:"enabled"`
+	Adult   bool   `mapstructure:"adult" json:"adult"`
 }
 
 // New loads configuration from file or environment variable
 func New() (*Config, error) {
-	viper.SetConfigType("yaml")
+	return Load()
+}
+
+// Load reads and validates configuration from the CONFIG_LOCAL environment
+// variable or the config files. Every call uses a fresh viper instance so it
+// can be used to reload configuration at runtime.
+func Load() (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	setDefaults(v)
 
 	// Check for CONFIG_LOCAL environment variable (base64 encoded, for Fly.io Secrets)
 	if configBase64 := os.Getenv("CONFIG_LOCAL"); configBase64 != "" {
@@ -67,25 +190,25 @@ func New() (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode CONFIG_LOCAL: %w", err)
 		}
-		if err := viper.ReadConfig(strings.NewReader(string(decoded))); err != nil {
+		if err := v.ReadConfig(strings.NewReader(string(decoded))); err != nil {
 			return nil, fmt.Errorf("failed to parse CONFIG_LOCAL: %w", err)
 		}
 	} else {
 		// Load from files
-		viper.SetConfigName("config")
-		viper.AddConfigPath("./configs")
+		v.SetConfigName("config")
+		v.AddConfigPath(ConfigDir)
 
-		if err := viper.ReadInConfig(); err != nil {
+		if err := v.ReadInConfig(); err != nil {
 			return nil, err
 		}
 
 		// Merge local config if exists (for sensitive data like sources)
-		viper.SetConfigName("config.local")
-		_ = viper.MergeInConfig() // Ignore error if not exists
+		v.SetConfigName("config.local")
+		_ = v.MergeInConfig() // Ignore error if not exists
 	}
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
 
@@ -97,11 +220,110 @@ func New() (*Config, error) {
 	return &cfg, nil
 }
 
+// setDefaults sets the values of settings added after the first releases, so
+// configs written before them, such as CONFIG_LOCAL which is not merged with
+// config.yaml, keep working
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("source.timeout", 5*time.Second)
+	v.SetDefault("auth.token_ttl", 24*time.Hour)
+	v.SetDefault("auth.lockout.enabled", true)
+	v.SetDefault("auth.lockout.max_attempts", 5)
+	v.SetDefault("auth.lockout.base_delay", time.Second)
+	v.SetDefault("auth.lockout.max_delay", 15*time.Minute)
+	v.SetDefault("auth.lockout.reset_after", time.Hour)
+	v.SetDefault("hls.max_ad_run_duration", 60*time.Second)
+}
+
 // validate checks config for errors
 func (c *Config) validate() error {
+	if err := ValidateSources(c.Sources); err != nil {
+		return err
+	}
+
+	if _, err := zerolog.ParseLevel(c.Log.Level); err != nil {
+		return fmt.Errorf("invalid log.level: %w", err)
+	}
+
+	if c.Source.Timeout <= 0 {
+		return fmt.Errorf("source.timeout must be positive")
+	}
+
+	if c.Source.Breaker.Enabled && c.Source.Breaker.FailureThreshold <= 0 {
+		return fmt.Errorf("source.breaker.failure_threshold must be positive")
+	}
+
+	if c.Search.Pinyin.Enabled && (c.Search.Pinyin.MaxTitles <= 0 || c.Search.Pinyin.MaxCandidates <= 0) {
+		return fmt.Errorf("search.pinyin.max_titles and max_candidates must be positive")
+	}
+
+	if err := validateRanking(c.Search.Ranking); err != nil {
+		return err
+	}
+
+	if err := validatePasswords(c.Auth.Passwords); err != nil {
+		return err
+	}
+
+	if c.Auth.TokenTTL <= 0 {
+		return fmt.Errorf("auth.token_ttl must be positive")
+	}
+
+	if c.Auth.TokenSecret != "" && len(c.Auth.TokenSecret) < minTokenSecretLen {
+		return fmt.Errorf("auth.token_secret must be at least %d characters", minTokenSecretLen)
+	}
+
+	if l := c.Auth.Lockout; l.Enabled {
+		if l.MaxAttempts <= 0 || l.BaseDelay <= 0 {
+			return fmt.Errorf("auth.lockout.max_attempts and base_delay must be positive")
+		}
+		if l.MaxDelay < l.BaseDelay || l.ResetAfter < l.MaxDelay {
+			return fmt.Errorf("auth.lockout requires base_delay <= max_delay <= reset_after")
+		}
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Token == "" {
+		return fmt.Errorf("metrics.token is required when metrics are enabled")
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing.sample_ratio must be between 0 and 1")
+		}
+	}
+
+	// Without trusted proxies any client could spoof its IP through the header
+	if c.Server.ProxyHeader != "" && len(c.Server.TrustedProxies) == 0 {
+		return fmt.Errorf("server.proxy_header requires server.trusted_proxies")
+	}
+	return nil
+}
+
+// validateRanking checks that ranking weights are not negative and source
+// weights lie in [0, 1]
+func validateRanking(r RankingConfig) error {
+	w := r.Weights
+	if w.Similarity < 0 || w.Tokens < 0 || w.Sources < 0 || w.Year < 0 || w.Quality < 0 {
+		return fmt.Errorf("search.ranking.weights must not be negative")
+	}
+	for code, weight := range r.SourceWeights {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("search.ranking.source_weights.%s must be between 0 and 1", code)
+		}
+	}
+	return nil
+}
+
+// ValidateSources checks a source list for errors
+func ValidateSources(sources []SourceItem) error {
 	// Check for duplicate source codes
 	seen := make(map[string]bool)
-	for _, s := range c.Sources {
+	for _, s := range sources {
+		if s.Code == "" {
+			return fmt.Errorf("source code is required")
+		}
 		if seen[s.Code] {
 			return fmt.Errorf("duplicate source code: %s", s.Code)
 		}
@@ -110,8 +332,57 @@ func (c *Config) validate() error {
 	return nil
 }
 
+// GetSources returns a copy of all configured sources
+func (c *Config) GetSources() []SourceItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]SourceItem(nil), c.Sources...)
+}
+
+// SetSources validates and atomically replaces the source list
+func (c *Config) SetSources(sources []SourceItem) error {
+	if err := ValidateSources(sources); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Sources = append([]SourceItem(nil), sources...)
+	return nil
+}
+
+// SetManagedSources replaces the source list with sources managed through the
+// admin API. From then on, file reloads no longer change sources.
+func (c *Config) SetManagedSources(sources []SourceItem) error {
+	if err := c.SetSources(sources); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sourcesManaged = true
+	return nil
+}
+
+// AuthEnabled reports whether password authentication is enabled
+func (c *Config) AuthEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Auth.Enabled
+}
+
+// SourceTimeout returns the timeout of a single source request
+func (c *Config) SourceTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Source.Timeout
+}
+
 // GetEnabledSources returns enabled sources
 func (c *Config) GetEnabledSources() []SourceItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	var enabled []SourceItem
 	for _, s := range c.Sources {
 		if s.Enabled {
@@ -123,6 +394,9 @@ func (c *Config) GetEnabledSources() []SourceItem {
 
 // GetSourceByCode returns a source by its code
 func (c *Config) GetSourceByCode(code string) (*SourceItem, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	for _, s := range c.Sources {
 		if s.Code == code {
 			return &s, true
@@ -131,16 +405,19 @@ func (c *Config) GetSourceByCode(code string) (*SourceItem, bool) {
 	return nil, false
 }
 
-// ValidatePassword checks if the password is in the whitelist and returns auth result
-func (c *Config) ValidatePassword(password string) AuthResult {
-	// If auth is disabled, allow everything including adult
-	if !c.Auth.Enabled {
-		return AuthResult{Valid: true, Adult: true}
-	}
+// Credentials returns the unexpired password entries with the profiles they
+// grant. Passwords are checked by the caller, outside the lock, as hashes are
+// slow to verify.
+func (c *Config) Credentials() []Credential {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	creds := make([]Credential, 0, len(c.Auth.Passwords))
 	for _, p := range c.Auth.Passwords {
-		if p.Password == password {
-			return AuthResult{Valid: true, Adult: p.Adult}
+		if !p.expired(now) {
+			creds = append(creds, Credential{Password: p.Password, Profile: p.profile()})
 		}
 	}
-	return AuthResult{Valid: false, Adult: false}
+	return creds
 }
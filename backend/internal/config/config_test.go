@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// loadLocal loads a config given through CONFIG_LOCAL, which is not merged
+// with configs/config.yaml
+func loadLocal(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	t.Setenv("CONFIG_LOCAL", base64.StdEncoding.EncodeToString([]byte(yaml)))
+	return Load()
+}
+
+func TestLoadDefaults(t *testing.T) {
+	// A config written before source.timeout, auth.token_ttl, auth.lockout and
+	// hls.max_ad_run_duration were read
+	cfg, err := loadLocal(t, `
+server:
+  host: "0.0.0.0"
+  port: 9898
+auth:
+  enabled: false
+sources: []
+`)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Source.Timeout != 5*time.Second {
+		t.Errorf("source.timeout = %s, want 5s", cfg.Source.Timeout)
+	}
+	if cfg.Auth.TokenTTL != 24*time.Hour {
+		t.Errorf("auth.token_ttl = %s, want 24h", cfg.Auth.TokenTTL)
+	}
+	wantLockout := LockoutConfig{
+		Enabled:     true,
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    15 * time.Minute,
+		ResetAfter:  time.Hour,
+	}
+	if cfg.Auth.Lockout != wantLockout {
+		t.Errorf("auth.lockout = %+v, want %+v", cfg.Auth.Lockout, wantLockout)
+	}
+	if cfg.HLS.MaxAdRunDuration != 60*time.Second {
+		t.Errorf("hls.max_ad_run_duration = %s, want 60s", cfg.HLS.MaxAdRunDuration)
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"zero timeout": `
+source:
+  timeout: 0s
+`,
+		"zero token ttl": `
+auth:
+  token_ttl: 0s
+`,
+		"negative ranking weight": `
+search:
+  ranking:
+    weights:
+      similarity: -1
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadLocal(t, yaml); err == nil {
+				t.Error("Load succeeded, want a validation error")
+			}
+		})
+	}
+}
+
+func TestApplySources(t *testing.T) {
+	a := SourceItem{Code: "a", Name: "A", URL: "https://a.example.com", Enabled: true}
+	b := SourceItem{Code: "b", Name: "B", URL: "https://b.example.com", Enabled: true}
+	c := SourceItem{Code: "c", Name: "C", URL: "https://c.example.com", Enabled: true}
+	bMoved := b
+	bMoved.URL = "https://b2.example.com"
+
+	tests := []struct {
+		name        string
+		managed     bool
+		next        []SourceItem
+		wantSources []string
+		wantIgnored bool
+		wantLive    int
+	}{
+		{name: "unchanged", next: []SourceItem{a, b}, wantLive: 2},
+		{name: "added changed removed", next: []SourceItem{bMoved, c}, wantSources: []string{"c", "a", "b"}, wantLive: 2},
+		{name: "managed", managed: true, next: []SourceItem{c}, wantIgnored: true, wantLive: 2},
+		{name: "managed unchanged", managed: true, next: []SourceItem{a, b}, wantLive: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Sources: []SourceItem{a, b}}
+			if tt.managed {
+				if err := cfg.SetManagedSources([]SourceItem{a, b}); err != nil {
+					t.Fatalf("SetManagedSources: %v", err)
+				}
+			}
+
+			result := cfg.Apply(&Config{Sources: tt.next})
+			if !slices.Equal(result.Sources, tt.wantSources) {
+				t.Errorf("Sources = %v, want %v", result.Sources, tt.wantSources)
+			}
+			if result.SourcesIgnored != tt.wantIgnored {
+				t.Errorf("SourcesIgnored = %v, want %v", result.SourcesIgnored, tt.wantIgnored)
+			}
+			if got := len(cfg.GetSources()); got != tt.wantLive {
+				t.Errorf("live sources = %d, want %d", got, tt.wantLive)
+			}
+		})
+	}
+}
+
+func TestWatcherReloadReportsSources(t *testing.T) {
+	t.Setenv("CONFIG_LOCAL", base64.StdEncoding.EncodeToString([]byte(`
+server:
+  host: "0.0.0.0"
+  port: 9898
+sources:
+  - code: "a"
+    name: "A"
+    url: "https://a2.example.com"
+    enabled: true
+`)))
+	cfg := &Config{
+		Log:     LogConfig{Level: "info"},
+		Sources: []SourceItem{{Code: "a", Name: "A", URL: "https://a.example.com", Enabled: true}},
+	}
+	logger := zerolog.Nop()
+	w := NewWatcher(cfg, &logger)
+
+	var forgotten []string
+	w.OnSourceChange(func(code string) { forgotten = append(forgotten, code) })
+	w.Reload()
+
+	if !slices.Equal(forgotten, []string{"a"}) {
+		t.Errorf("forgotten = %v, want [a]", forgotten)
+	}
+	if src, _ := cfg.GetSourceByCode("a"); src.URL != "https://a2.example.com" {
+		t.Errorf("source url = %s, want the reloaded one", src.URL)
+	}
+}
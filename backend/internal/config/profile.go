@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// expiresDateLayout is the date-only format accepted by auth.passwords[].expires
+const expiresDateLayout = "2006-01-02"
+
+// Profile is the identity and access rules of an authenticated caller
+type Profile struct {
+	Name         string
+	Adult        bool
+	Admin        bool
+	AllowSources []string  // Only these source codes, empty allows all
+	DenySources  []string  // Never these source codes
+	Categories   []string  // Allowed type_name values, empty allows all
+	ExpiresAt    time.Time // Zero never expires
+}
+
+// AllowsSource reports whether the profile may use a source
+func (p *Profile) AllowsSource(src SourceItem) bool {
+	if src.Adult && !p.Adult {
+		return false
+	}
+	if slices.Contains(p.DenySources, src.Code) {
+		return false
+	}
+	return len(p.AllowSources) == 0 || slices.Contains(p.AllowSources, src.Code)
+}
+
+// AllowsCategory reports whether the profile may see videos of a category
+func (p *Profile) AllowsCategory(typeName string) bool {
+	return len(p.Categories) == 0 || slices.Contains(p.Categories, strings.TrimSpace(typeName))
+}
+
+// profile returns the access rules of a password entry
+func (p PasswordItem) profile() Profile {
+	expires, _ := parseExpires(p.Expires)
+	return Profile{
+		Name:         p.Name,
+		Adult:        p.Adult,
+		Admin:        p.Admin,
+		AllowSources: p.AllowSources,
+		DenySources:  p.DenySources,
+		Categories:   p.Categories,
+		ExpiresAt:    expires,
+	}
+}
+
+// expired reports whether a password entry is past its expiry
+func (p PasswordItem) expired(now time.Time) bool {
+	expires, _ := parseExpires(p.Expires)
+	return !expires.IsZero() && !now.Before(expires)
+}
+
+// restricted reports whether a password entry carries access rules that
+// need a profile name to survive in login tokens
+func (p PasswordItem) restricted() bool {
+	return len(p.AllowSources) > 0 || len(p.DenySources) > 0 || len(p.Categories) > 0 || p.Expires != ""
+}
+
+// parseExpires parses an expiry given as a date, which expires at the end of
+// that day in UTC, or as an RFC 3339 time
+func parseExpires(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(expiresDateLayout, value); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected %s or RFC 3339 time: %q", expiresDateLayout, value)
+	}
+	return t, nil
+}
+
+// validatePasswords checks password entries for errors
+func validatePasswords(passwords []PasswordItem) error {
+	names := make(map[string]bool)
+	for i, p := range passwords {
+		if p.Name != "" {
+			if names[p.Name] {
+				return fmt.Errorf("duplicate auth.passwords name: %s", p.Name)
+			}
+			names[p.Name] = true
+		} else if p.restricted() {
+			return fmt.Errorf("auth.passwords[%d]: name is required for access rules and expiry", i)
+		}
+
+		if _, err := parseExpires(p.Expires); err != nil {
+			return fmt.Errorf("auth.passwords[%d].expires: %w", i, err)
+		}
+	}
+	return nil
+}
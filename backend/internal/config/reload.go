@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ReloadResult describes what a reload applied
+type ReloadResult struct {
+	Changes        []string // Description of every change
+	Sources        []string // Codes of the sources added, changed or removed
+	SourcesIgnored bool     // The file's sources were dropped, sources are managed through the admin API
+}
+
+// Apply atomically applies the runtime-changeable settings of next (sources,
+// auth, source timeout and log level) and describes every change. Other
+// settings are reported as needing a restart but not applied. Once sources are
+// managed through the admin API, the sources of next are ignored.
+func (c *Config) Apply(next *Config) ReloadResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result ReloadResult
+	var changes []string
+
+	if c.sourcesManaged {
+		if !reflect.DeepEqual(c.Sources, next.Sources) {
+			changes = append(changes, "sources: ignored, managed through the admin API")
+			result.SourcesIgnored = true
+		}
+	} else {
+		var sourceChanges []string
+		sourceChanges, result.Sources = diffSources(c.Sources, next.Sources)
+		changes = append(changes, sourceChanges...)
+		c.Sources = append([]SourceItem(nil), next.Sources...)
+	}
+
+	if c.Auth.Enabled != next.Auth.Enabled {
+		changes = append(changes, fmt.Sprintf("auth.enabled: %t -> %t", c.Auth.Enabled, next.Auth.Enabled))
+	}
+	if !reflect.DeepEqual(c.Auth.Passwords, next.Auth.Passwords) {
+		changes = append(changes, fmt.Sprintf("auth.passwords: %d -> %d entries", len(c.Auth.Passwords), len(next.Auth.Passwords)))
+	}
+	c.Auth.Enabled = next.Auth.Enabled
+	c.Auth.Passwords = next.Auth.Passwords
+
+	if c.Source.Timeout != next.Source.Timeout {
+		changes = append(changes, fmt.Sprintf("source.timeout: %s -> %s", c.Source.Timeout, next.Source.Timeout))
+		c.Source.Timeout = next.Source.Timeout
+	}
+
+	if c.Log.Level != next.Log.Level {
+		changes = append(changes, fmt.Sprintf("log.level: %s -> %s", c.Log.Level, next.Log.Level))
+		c.Log.Level = next.Log.Level
+	}
+
+	// Settings read once at startup
+	restart := map[string]bool{
+		"server":         !reflect.DeepEqual(c.Server, next.Server),
+		"log.format":     c.Log.Format != next.Log.Format,
+		"source.retry":   c.Source.Retry != next.Source.Retry,
+		"source.breaker": !reflect.DeepEqual(c.Source.Breaker, next.Source.Breaker),
+		"search.pinyin":  c.Search.Pinyin != next.Search.Pinyin,
+		"search.ranking": !reflect.DeepEqual(c.Search.Ranking, next.Search.Ranking),
+		"cache":          !reflect.DeepEqual(c.Cache, next.Cache),
+		"hls":            !reflect.DeepEqual(c.HLS, next.HLS),
+		"admin":          !reflect.DeepEqual(c.Admin, next.Admin),
+		"metrics":        c.Metrics != next.Metrics,
+		"tracing":        c.Tracing != next.Tracing,
+		"auth.token":     c.Auth.TokenSecret != next.Auth.TokenSecret || c.Auth.TokenTTL != next.Auth.TokenTTL,
+		"auth.lockout":   c.Auth.Lockout != next.Auth.Lockout,
+	}
+	for _, key := range sortedKeys(restart) {
+		if restart[key] {
+			changes = append(changes, key+": changed, requires restart")
+		}
+	}
+
+	result.Changes = changes
+	return result
+}
+
+// LogLevel returns the configured log level
+func (c *Config) LogLevel() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Log.Level
+}
+
+// diffSources describes added, removed and changed sources and returns their codes
+func diffSources(old, next []SourceItem) ([]string, []string) {
+	before := make(map[string]SourceItem, len(old))
+	for _, s := range old {
+		before[s.Code] = s
+	}
+
+	var added, changed []string
+	after := make(map[string]bool, len(next))
+	for _, s := range next {
+		after[s.Code] = true
+		prev, ok := before[s.Code]
+		switch {
+		case !ok:
+			added = append(added, s.Code)
+		case prev != s:
+			changed = append(changed, s.Code)
+		}
+	}
+
+	var removed []string
+	for _, s := range old {
+		if !after[s.Code] {
+			removed = append(removed, s.Code)
+		}
+	}
+
+	var changes []string
+	if len(added) > 0 {
+		changes = append(changes, "sources added: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, "sources removed: "+strings.Join(removed, ", "))
+	}
+	if len(changed) > 0 {
+		changes = append(changes, "sources changed: "+strings.Join(changed, ", "))
+	}
+
+	codes := append(append(added, removed...), changed...)
+	return changes, codes
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
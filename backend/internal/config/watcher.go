@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// reloadDebounce collapses the burst of events editors emit when saving
+const reloadDebounce = 300 * time.Millisecond
+
+// watchedFiles are the config files whose changes trigger a reload
+var watchedFiles = map[string]bool{
+	"config.yaml":       true,
+	"config.local.yaml": true,
+}
+
+// Watcher reloads configuration when the config files change or the process
+// receives SIGHUP. Invalid configurations are rejected and the running
+// configuration is kept.
+type Watcher struct {
+	config  *Config
+	logger  *zerolog.Logger
+	fs      *fsnotify.Watcher
+	signals chan os.Signal
+	done    chan struct{}
+
+	// onSourceChange is called for every source a reload adds, changes or removes
+	onSourceChange func(code string)
+}
+
+// NewWatcher creates a config watcher
+func NewWatcher(cfg *Config, logger *zerolog.Logger) *Watcher {
+	return &Watcher{
+		config:  cfg,
+		logger:  logger,
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// OnSourceChange sets the function called for every source a reload adds,
+// changes or removes, before the reload is logged. Must be called before Start.
+func (w *Watcher) OnSourceChange(fn func(code string)) {
+	w.onSourceChange = fn
+}
+
+// Start starts watching the config directory and SIGHUP.
+// Files are not watched when configuration comes from CONFIG_LOCAL.
+func (w *Watcher) Start() error {
+	if os.Getenv("CONFIG_LOCAL") == "" {
+		fs, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		// Watch the directory so files replaced by rename are picked up
+		if err := fs.Add(ConfigDir); err != nil {
+			fs.Close()
+			return err
+		}
+		w.fs = fs
+	}
+
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go w.run()
+
+	w.logger.Info().Bool("files", w.fs != nil).Msg("config watcher started")
+	return nil
+}
+
+// Stop stops the watcher
+func (w *Watcher) Stop() {
+	signal.Stop(w.signals)
+	close(w.done)
+	if w.fs != nil {
+		w.fs.Close()
+	}
+}
+
+// Reload loads the configuration and applies it if valid
+func (w *Watcher) Reload() {
+	next, err := Load()
+	if err != nil {
+		w.logger.Error().Err(err).Msg("config reload rejected")
+		return
+	}
+
+	result := w.config.Apply(next)
+	if level, err := zerolog.ParseLevel(w.config.LogLevel()); err == nil {
+		zerolog.SetGlobalLevel(level)
+	}
+	if w.onSourceChange != nil {
+		for _, code := range result.Sources {
+			w.onSourceChange(code)
+		}
+	}
+	if result.SourcesIgnored {
+		w.logger.Warn().
+			Str("store", next.Admin.SourcesStore).
+			Msg("sources in config files ignored, they are managed through the admin API and its store")
+	}
+
+	if len(result.Changes) == 0 {
+		w.logger.Info().Msg("config reloaded, no changes")
+		return
+	}
+	w.logger.Info().Strs("changes", result.Changes).Msg("config reloaded")
+}
+
+func (w *Watcher) run() {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w.fs != nil {
+		events = w.fs.Events
+		errs = w.fs.Errors
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			debounce.Stop()
+			return
+		case <-w.signals:
+			w.logger.Info().Msg("SIGHUP received, reloading config")
+			w.Reload()
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if watchedFiles[filepath.Base(ev.Name)] && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				debounce.Reset(reloadDebounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			w.logger.Warn().Err(err).Msg("config watcher error")
+		case <-debounce.C:
+			w.logger.Info().Msg("config file changed, reloading")
+			w.Reload()
+		}
+	}
+}
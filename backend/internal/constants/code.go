@@ -1,13 +1,28 @@
 package constants
 
-// Code represents a business status code
+import "net/http"
+
+// Code represents a business status code.
+// Code doubles as the HTTP status of the response, Error is a stable
+// machine-readable identifier clients can match on.
 type Code struct {
 	Code    int
+	Error   string
 	Message string
 }
 
 var (
-	Success       = Code{200, "success"}
-	InvalidParams = Code{400, "invalid parameters"}
-	InternalError = Code{500, "internal server error"}
+	Success             = Code{http.StatusOK, "", "success"}
+	InvalidParams       = Code{http.StatusBadRequest, "invalid_params", "invalid parameters"}
+	Unauthorized        = Code{http.StatusUnauthorized, "unauthorized", "unauthorized"}
+	Forbidden           = Code{http.StatusForbidden, "forbidden", "forbidden"}
+	NotFound            = Code{http.StatusNotFound, "not_found", "not found"}
+	SourceNotFound      = Code{http.StatusNotFound, "source_not_found", "source not found"}
+	VideoNotFound       = Code{http.StatusNotFound, "video_not_found", "video not found"}
+	TooManyRequests     = Code{http.StatusTooManyRequests, "too_many_requests", "too many failed attempts"}
+	InternalError       = Code{http.StatusInternalServerError, "internal_error", "internal server error"}
+	UpstreamError       = Code{http.StatusBadGateway, "upstream_error", "upstream request failed"}
+	UpstreamBadPayload  = Code{http.StatusBadGateway, "upstream_bad_payload", "bad upstream payload"}
+	UpstreamUnavailable = Code{http.StatusServiceUnavailable, "upstream_unavailable", "upstream temporarily unavailable"}
+	UpstreamTimeout     = Code{http.StatusGatewayTimeout, "upstream_timeout", "upstream timeout"}
 )
@@ -0,0 +1,41 @@
+package constants
+
+import "errors"
+
+// Error is a domain error answered with its code.
+// Errors are compared by identity, wrap them with fmt.Errorf("%w: ...") to
+// add details.
+type Error struct {
+	Code Code
+}
+
+func (e *Error) Error() string {
+	return e.Code.Message
+}
+
+var (
+	// ErrSourceNotFound indicates no source has the requested code
+	ErrSourceNotFound = &Error{SourceNotFound}
+	// ErrVideoNotFound indicates the source has no video with the requested ID
+	ErrVideoNotFound = &Error{VideoNotFound}
+	// ErrForbidden indicates the caller may not access the resource
+	ErrForbidden = &Error{Forbidden}
+	// ErrUpstreamTimeout indicates a source did not answer in time
+	ErrUpstreamTimeout = &Error{UpstreamTimeout}
+	// ErrUpstreamBadPayload indicates a source answered with an undecodable payload
+	ErrUpstreamBadPayload = &Error{UpstreamBadPayload}
+	// ErrUpstreamError indicates a source answered with an error status
+	ErrUpstreamError = &Error{UpstreamError}
+	// ErrUpstreamUnavailable indicates a source is skipped by its circuit breaker
+	ErrUpstreamUnavailable = &Error{UpstreamUnavailable}
+)
+
+// CodeOf returns the code of the domain error in err's chain, InternalError
+// if there is none
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return InternalError
+}
@@ -1,13 +1,20 @@
 package dto
 
-import "searchav/internal/model"
+import (
+	"searchav/internal/config"
+	"searchav/internal/model"
+	"searchav/internal/source"
+)
 
 // Response is the unified response structure
 type Response struct {
 	Code    int         `json:"code"`
+	Error   string      `json:"error,omitempty"` // Stable error identifier, empty on success
 	Message string      `json:"msg"`
 	Data    interface{} `json:"data,omitempty"`
 	List    interface{} `json:"list,omitempty"`
+	Sources interface{} `json:"sources,omitempty"`
+	Facets  interface{} `json:"facets,omitempty"`
 }
 
 // WithCode sets the status code
@@ -16,6 +23,12 @@ func (r *Response) WithCode(code int) *Response {
 	return r
 }
 
+// WithError sets the error identifier
+func (r *Response) WithError(err string) *Response {
+	r.Error = err
+	return r
+}
+
 // WithMessage sets the message
 func (r *Response) WithMessage(msg string) *Response {
 	r.Message = msg
@@ -34,11 +47,31 @@ func (r *Response) WithList(list interface{}) *Response {
 	return r
 }
 
+// WithSources sets the per-source status report
+func (r *Response) WithSources(sources interface{}) *Response {
+	r.Sources = sources
+	return r
+}
+
+// WithFacets sets the facet counts of a search
+func (r *Response) WithFacets(facets interface{}) *Response {
+	r.Facets = facets
+	return r
+}
+
+// SuccessResponse is the response structure of requests without a payload
+type SuccessResponse struct {
+	Code    int    `json:"code" example:"200"`
+	Message string `json:"msg" example:"success"`
+}
+
 // SearchResponse is the search response structure
 type SearchResponse struct {
-	Code    int               `json:"code" example:"200"`
-	Message string            `json:"msg" example:"success"`
-	List    []model.VideoItem `json:"list"`
+	Code    int                  `json:"code" example:"200"`
+	Message string               `json:"msg" example:"success"`
+	List    []model.VideoItem    `json:"list"`
+	Sources []model.SourceStatus `json:"sources"`
+	Facets  model.SearchFacets   `json:"facets"`
 }
 
 // DetailResponse is the detail response structure
@@ -48,8 +81,53 @@ type DetailResponse struct {
 	Data    model.VideoDetail `json:"data"`
 }
 
+// SourcesResponse is the source list response structure
+type SourcesResponse struct {
+	Code    int                    `json:"code" example:"200"`
+	Message string                 `json:"msg" example:"success"`
+	List    []model.SourceListItem `json:"list"`
+}
+
+// SourceHealthResponse is the source health response structure
+type SourceHealthResponse struct {
+	Code    int             `json:"code" example:"200"`
+	Message string          `json:"msg" example:"success"`
+	List    []source.Health `json:"list"`
+}
+
+// AdminSourcesResponse is the admin source list response structure
+type AdminSourcesResponse struct {
+	Code    int                 `json:"code" example:"200"`
+	Message string              `json:"msg" example:"success"`
+	List    []config.SourceItem `json:"list"`
+}
+
+// LoginRequest is the login request body
+type LoginRequest struct {
+	Password string `json:"password"`
+}
+
+// LoginResponse is the login response structure
+type LoginResponse struct {
+	Code    int               `json:"code" example:"200"`
+	Message string            `json:"msg" example:"success"`
+	Data    model.LoginResult `json:"data"`
+}
+
 // ErrorResponse is the error response structure
 type ErrorResponse struct {
-	Code    int    `json:"code" example:"400"`
-	Message string `json:"msg" example:"bad request"`
+	Code    int    `json:"code" example:"404"`
+	Error   string `json:"error" example:"source_not_found"`
+	Message string `json:"msg" example:"source not found: abc"`
+}
+
+// Problem is the RFC 7807 problem details structure, returned instead of
+// ErrorResponse to clients accepting application/problem+json
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"source not found: abc"`
+	Instance string `json:"instance,omitempty" example:"/api/detail?source=abc&id=1"`
+	Code     string `json:"code" example:"source_not_found"`
 }
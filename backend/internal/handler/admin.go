@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+
+	"searchav/internal/config"
+	_ "searchav/internal/dto"
+	"searchav/internal/service"
+)
+
+// AdminHandler handles source management requests
+type AdminHandler struct {
+	service *service.AdminService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(service *service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+	}
+}
+
+// ListSources handles admin source list requests
+// @Summary List all sources (admin)
+// @Description List every source including disabled ones. Requires an admin password.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.AdminSourcesResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/sources [get]
+func (h *AdminHandler) ListSources(ctx *Context) error {
+	return ctx.SuccessWithList(h.service.ListSources())
+}
+
+// CreateSource handles source creation requests
+// @Summary Create a source (admin)
+// @Description Validate a source with a test query, persist it and start using it without a restart
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param source body config.SourceItem true "Source"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/sources [post]
+func (h *AdminHandler) CreateSource(ctx *Context) error {
+	var item config.SourceItem
+	if err := ctx.BodyParser(&item); err != nil {
+		return ctx.BadRequest("invalid request body")
+	}
+
+	if err := h.service.CreateSource(ctx.UserContext(), item); err != nil {
+		return h.error(ctx, err)
+	}
+
+	ctx.Logger.Info().Str("source", item.Code).Msg("source created")
+	return ctx.Success()
+}
+
+// UpdateSource handles source update requests
+// @Summary Update a source (admin)
+// @Description Validate a source with a test query, persist it and start using it without a restart
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param code path string true "Source code"
+// @Param source body config.SourceItem true "Source"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/sources/{code} [put]
+func (h *AdminHandler) UpdateSource(ctx *Context) error {
+	var item config.SourceItem
+	if err := ctx.BodyParser(&item); err != nil {
+		return ctx.BadRequest("invalid request body")
+	}
+
+	code := ctx.Params("code")
+	if err := h.service.UpdateSource(ctx.UserContext(), code, item); err != nil {
+		return h.error(ctx, err)
+	}
+
+	ctx.Logger.Info().Str("source", code).Msg("source updated")
+	return ctx.Success()
+}
+
+// DeleteSource handles source deletion requests
+// @Summary Delete a source (admin)
+// @Tags admin
+// @Produce json
+// @Param code path string true "Source code"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/sources/{code} [delete]
+func (h *AdminHandler) DeleteSource(ctx *Context) error {
+	code := ctx.Params("code")
+	if err := h.service.DeleteSource(code); err != nil {
+		return h.error(ctx, err)
+	}
+
+	ctx.Logger.Info().Str("source", code).Msg("source deleted")
+	return ctx.Success()
+}
+
+// error maps admin service errors to responses
+func (h *AdminHandler) error(ctx *Context, err error) error {
+	if errors.Is(err, service.ErrInvalidSource) || errors.Is(err, service.ErrSourceExists) {
+		return ctx.BadRequest(err.Error())
+	}
+	return ctx.Error(err)
+}
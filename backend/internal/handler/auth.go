@@ -1,7 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
 	"searchav/internal/config"
+	"searchav/internal/constants"
+	"searchav/internal/dto"
+	"searchav/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,26 +17,36 @@ import (
 const (
 	// AuthHeader is the header name for password authentication
 	AuthHeader = "X-Auth-Password"
+	// BearerPrefix precedes the session token in the Authorization header
+	BearerPrefix = "Bearer "
 	// AdultPermKey is the context key for adult permission
 	AdultPermKey = "adult_perm"
+	// AdminPermKey is the context key for admin permission
+	AdminPermKey = "admin_perm"
+	// ProfileKey is the context key for the caller's profile
+	ProfileKey = "profile"
 )
 
-// AuthMiddleware creates a password authentication middleware
-func AuthMiddleware(cfg *config.Config) fiber.Handler {
+// AuthMiddleware creates an authentication middleware accepting a session
+// token in the Authorization header or a password in X-Auth-Password
+func AuthMiddleware(authService *service.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		password := c.Get(AuthHeader)
-		result := cfg.ValidatePassword(password)
-
-		// If auth is enabled and password is invalid, return 401
-		if cfg.Auth.Enabled && !result.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"code": 401,
-				"msg":  "unauthorized",
-			})
+		token := bearerToken(c.Get(fiber.HeaderAuthorization))
+		result, err := authService.Authenticate(GetClientIP(c), token, c.Get(AuthHeader))
+		var locked *service.LockedOutError
+		if errors.As(err, &locked) {
+			return tooManyAttempts(c, locked)
+		}
+
+		// If auth is enabled and credentials are invalid, return 401
+		if !result.Valid {
+			return writeError(c, constants.Unauthorized, "")
 		}
 
-		// Store adult permission in context for later use
+		// Store permissions in context for later use
 		c.Locals(AdultPermKey, result.Adult)
+		c.Locals(AdminPermKey, result.Admin)
+		c.Locals(ProfileKey, &result.Profile)
 
 		return c.Next()
 	}
@@ -41,3 +59,114 @@ func GetAdultPerm(c *fiber.Ctx) bool {
 	}
 	return false
 }
+
+// GetProfile retrieves the caller's profile from context.
+// Without one, an empty profile without any permission is returned.
+func GetProfile(c *fiber.Ctx) *config.Profile {
+	if profile, ok := c.Locals(ProfileKey).(*config.Profile); ok {
+		return profile
+	}
+	return &config.Profile{}
+}
+
+// AdminMiddleware rejects callers without admin permission.
+// It must run after AuthMiddleware.
+func AdminMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !GetAdminPerm(c) {
+			return writeError(c, constants.Forbidden, "")
+		}
+		return c.Next()
+	}
+}
+
+// AuthorizeMiddleware rejects requests naming a source, through the source or
+// sources query parameter, that the caller's profile may not use: disabled
+// sources, adult sources for non-adult profiles and sources outside the
+// profile's rules all get 403. Unknown codes are left to the handlers.
+// It must run after AuthMiddleware.
+func AuthorizeMiddleware(authz *service.Authorizer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		codes := parseSourceCodes(c.Query("sources"))
+		if code := strings.TrimSpace(c.Query("source")); code != "" {
+			codes = append(codes, code)
+		}
+
+		profile := GetProfile(c)
+		for _, code := range codes {
+			if _, err := authz.Source(profile, code); errors.Is(err, service.ErrForbidden) {
+				return writeError(c, constants.Forbidden, err.Error())
+			}
+		}
+		return c.Next()
+	}
+}
+
+// GetAdminPerm retrieves the admin permission from context
+func GetAdminPerm(c *fiber.Ctx) bool {
+	if perm, ok := c.Locals(AdminPermKey).(bool); ok {
+		return perm
+	}
+	return false
+}
+
+// tooManyAttempts answers 429 to a client locked out after failed password attempts
+func tooManyAttempts(c *fiber.Ctx, locked *service.LockedOutError) error {
+	seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return writeError(c, constants.TooManyRequests, "")
+}
+
+// bearerToken extracts the token from an Authorization header value
+func bearerToken(header string) string {
+	if len(header) < len(BearerPrefix) || !strings.EqualFold(header[:len(BearerPrefix)], BearerPrefix) {
+		return ""
+	}
+	return header[len(BearerPrefix):]
+}
+
+// AuthHandler handles login requests
+type AuthHandler struct {
+	service *service.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(service *service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+// Login handles login requests
+// @Summary Log in
+// @Description Exchange a password for a signed, expiring session token carrying its permissions.
+// @Description Send the token as "Authorization: Bearer <token>" instead of X-Auth-Password.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginRequest true "Password"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(ctx *Context) error {
+	var req dto.LoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.BadRequest("invalid request body")
+	}
+
+	result, err := h.service.Login(GetClientIP(ctx.Ctx), req.Password)
+	var locked *service.LockedOutError
+	if errors.As(err, &locked) {
+		return tooManyAttempts(ctx.Ctx, locked)
+	}
+	if errors.Is(err, service.ErrInvalidPassword) {
+		ctx.Logger.Warn().Str("ip", GetClientIP(ctx.Ctx)).Msg("login failed")
+		return writeError(ctx.Ctx, constants.Unauthorized, err.Error())
+	}
+	if err != nil {
+		return ctx.InternalError(err)
+	}
+
+	return ctx.SuccessWithData(result)
+}
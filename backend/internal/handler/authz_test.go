@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/metrics"
+	"searchav/internal/service"
+	"searchav/internal/source"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// stubProvider answers every source with the same video
+type stubProvider struct{}
+
+func (stubProvider) Search(_ context.Context, _ config.SourceItem, keyword string) ([]source.RawVideo, error) {
+	return []source.RawVideo{{VodID: 1, VodName: keyword, TypeName: "电影"}}, nil
+}
+
+func (stubProvider) GetDetail(_ context.Context, _ config.SourceItem, vodID int) (*source.RawVideo, error) {
+	return &source.RawVideo{VodID: vodID, VodName: "video", TypeName: "电影", VodPlayFrom: "m3u8", VodPlayURL: "第1集$https://cdn.example.com/1.m3u8"}, nil
+}
+
+func (stubProvider) ListCategories(context.Context, config.SourceItem) ([]source.Category, error) {
+	return nil, nil
+}
+
+// stubFetcher serves the same media playlist for every URL
+type stubFetcher struct{}
+
+func (stubFetcher) Fetch(context.Context, string) ([]byte, error) {
+	return []byte("#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n#EXT-X-ENDLIST\n"), nil
+}
+
+// newAPIApp wires the API routes as the server does, over stub sources
+func newAPIApp(t *testing.T) *fiber.App {
+	t.Helper()
+	cfg := &config.Config{
+		Source: config.SourceConfig{Timeout: 5 * time.Second},
+		Auth: config.AuthConfig{
+			Enabled:     true,
+			TokenSecret: "test-secret",
+			TokenTTL:    time.Hour,
+			Passwords: []config.PasswordItem{
+				{Password: "full", Adult: true},
+				{Name: "kid", Password: "kid", AllowSources: []string{"pub"}},
+				{Name: "old", Password: "old", Expires: "2020-01-01"},
+			},
+		},
+		Sources: []config.SourceItem{
+			{Code: "pub", Name: "Public", URL: "https://pub.example.com", Enabled: true},
+			{Code: "adult", Name: "Adult", URL: "https://adult.example.com", Adult: true, Enabled: true},
+			{Code: "off", Name: "Off", URL: "https://off.example.com"},
+		},
+	}
+	logger := zerolog.Nop()
+
+	client := source.NewClient(cfg, metrics.New(), &logger)
+	client.Register(source.TypeMacCMSJSON, stubProvider{})
+	authService, err := service.NewAuthService(cfg, &logger)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	searchService, err := service.NewSearchService(cfg, client, metrics.New(), &logger)
+	if err != nil {
+		t.Fatalf("NewSearchService: %v", err)
+	}
+	authz := service.NewAuthorizer(cfg)
+	playHosts := service.NewPlayHosts()
+	ctxHandler := NewContextHandler(&logger)
+	searchHandler := NewSearchHandler(searchService)
+	detailHandler := NewDetailHandler(service.NewDetailService(cfg, client, authz, playHosts, &logger))
+	hlsHandler := NewHLSHandler(service.NewHLSService(cfg, stubFetcher{}, authz, playHosts, &logger))
+	sourceHandler := NewSourceHandler(service.NewSourceService(cfg, client, &logger))
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	api := app.Group("/api", AuthMiddleware(authService), AuthorizeMiddleware(authz))
+	api.Get("/search", ctxHandler.Wrap(searchHandler.Search))
+	api.Get("/search/stream", ctxHandler.Wrap(searchHandler.SearchStream))
+	api.Get("/detail", ctxHandler.Wrap(detailHandler.GetDetail))
+	api.Get("/hls/playlist", ctxHandler.Wrap(hlsHandler.Playlist))
+	api.Get("/sources", ctxHandler.Wrap(sourceHandler.List))
+	return app
+}
+
+func TestAPIAuthorization(t *testing.T) {
+	const playlist = "&url=https://media.pub.example.com/1.m3u8"
+	tests := []struct {
+		name     string
+		password string
+		path     string
+		want     int
+		contains string
+	}{
+		{"search allowed", "kid", "/api/search?q=video&sources=pub", fiber.StatusOK, `"source_code":"pub"`},
+		{"search denied source", "kid", "/api/search?q=video&sources=adult", fiber.StatusForbidden, ""},
+		{"search disabled source", "full", "/api/search?q=video&sources=off", fiber.StatusForbidden, ""},
+		{"search expired", "old", "/api/search?q=video", fiber.StatusUnauthorized, ""},
+		{"stream allowed", "kid", "/api/search/stream?q=video&sources=pub", fiber.StatusOK, "event: done"},
+		{"stream denied source", "kid", "/api/search/stream?q=video&sources=adult", fiber.StatusForbidden, ""},
+		{"stream expired", "old", "/api/search/stream?q=video", fiber.StatusUnauthorized, ""},
+		{"stream invalid year", "kid", "/api/search/stream?q=video&year=20x1", fiber.StatusBadRequest, ""},
+		{"stream facets", "kid", "/api/search/stream?q=video&type=movie", fiber.StatusOK, `"facets":{"type":[{"value":"movie","count":1}]`},
+		{"detail allowed", "full", "/api/detail?source=adult&id=1", fiber.StatusOK, `"vod_name":"video"`},
+		{"detail denied source", "kid", "/api/detail?source=adult&id=1", fiber.StatusForbidden, ""},
+		{"detail expired", "old", "/api/detail?source=pub&id=1", fiber.StatusUnauthorized, ""},
+		{"hls allowed", "kid", "/api/hls/playlist?source=pub" + playlist, fiber.StatusOK, "seg1.ts"},
+		{"hls foreign host", "kid", "/api/hls/playlist?source=pub&url=https://evil.example.net/1.m3u8", fiber.StatusForbidden, ""},
+		{"hls without source", "full", "/api/hls/playlist?" + playlist[1:], fiber.StatusBadRequest, ""},
+		{"hls denied source", "kid", "/api/hls/playlist?source=adult" + playlist, fiber.StatusForbidden, ""},
+		{"hls disabled source", "full", "/api/hls/playlist?source=off" + playlist, fiber.StatusForbidden, ""},
+		{"hls unknown source", "full", "/api/hls/playlist?source=nope" + playlist, fiber.StatusNotFound, ""},
+		{"hls expired", "old", "/api/hls/playlist?source=pub" + playlist, fiber.StatusUnauthorized, ""},
+		{"sources allowed", "full", "/api/sources", fiber.StatusOK, `"code":"adult"`},
+		{"sources expired", "old", "/api/sources", fiber.StatusUnauthorized, ""},
+	}
+
+	app := newAPIApp(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			req.Header.Set(AuthHeader, tt.password)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.want, body)
+			}
+			if !strings.Contains(string(body), tt.contains) {
+				t.Errorf("body does not contain %s: %s", tt.contains, body)
+			}
+		})
+	}
+}
+
+// Profiles only see the sources they may use
+func TestSourcesListFiltered(t *testing.T) {
+	app := newAPIApp(t)
+	req := httptest.NewRequest(fiber.MethodGet, "/api/sources", nil)
+	req.Header.Set(AuthHeader, "kid")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"code":"pub"`) || strings.Contains(string(body), `"code":"adult"`) {
+		t.Errorf("kid profile sources = %s, want only pub", body)
+	}
+}
+
+// Playlists on hosts other than the source's API host are proxied once the
+// source returned play URLs on them
+func TestHLSPlayHosts(t *testing.T) {
+	app := newAPIApp(t)
+	get := func(path string) int {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set(AuthHeader, "full")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("Test: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	const playlist = "/api/hls/playlist?source=pub&url=https://cdn.example.com/1.m3u8"
+	if status := get(playlist); status != fiber.StatusForbidden {
+		t.Fatalf("before detail: status = %d, want 403", status)
+	}
+	if status := get("/api/detail?source=pub&id=1"); status != fiber.StatusOK {
+		t.Fatalf("detail: status = %d, want 200", status)
+	}
+	if status := get(playlist); status != fiber.StatusOK {
+		t.Errorf("after detail: status = %d, want 200", status)
+	}
+	if status := get("/api/hls/playlist?source=adult&url=https://cdn.example.com/1.m3u8"); status != fiber.StatusForbidden {
+		t.Errorf("other source: status = %d, want 403", status)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIPKey is the context key for the client IP
+const ClientIPKey = "client_ip"
+
+// ClientIPMiddleware resolves the client IP of requests coming through
+// trusted proxies from the proxy header.
+//
+// Proxies append the address they received a request from, so only the
+// right end of a header such as X-Forwarded-For can be trusted: the header is
+// walked from the right, skipping trusted proxies, and the first other
+// address is the client. Anything left of it was sent by the client.
+// Without a proxy header the peer address is the client IP.
+func ClientIPMiddleware(header string, trustedProxies []string) (fiber.Handler, error) {
+	trusted := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", proxy, err)
+		}
+		trusted = append(trusted, prefix)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *fiber.Ctx) error {
+		ip := c.IP()
+		if header != "" {
+			ip = forwardedFor(ip, c.Get(header), isTrusted)
+		}
+		c.Locals(ClientIPKey, ip)
+		return c.Next()
+	}, nil
+}
+
+// GetClientIP retrieves the client IP from context, or the peer address
+// when it was not resolved
+func GetClientIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(ClientIPKey).(string); ok {
+		return ip
+	}
+	return c.IP()
+}
+
+// forwardedFor returns the right-most untrusted address of a forwarding
+// header received from peer. If every hop is trusted the left-most valid one
+// is used, and a malformed hop ends the walk.
+func forwardedFor(peer, header string, isTrusted func(netip.Addr) bool) string {
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !isTrusted(addr.Unmap()) {
+		return peer
+	}
+
+	ip := peer
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = addr.Unmap()
+		ip = addr.String()
+		if !isTrusted(addr) {
+			break
+		}
+	}
+	return ip
+}
+
+// parsePrefix parses an IP or CIDR, a single IP matching only itself
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
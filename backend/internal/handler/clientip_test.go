@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// Requests made through fiber.App.Test come from 0.0.0.0, which the tests
+// treat as the reverse proxy in front of the server
+var testProxies = []string{"0.0.0.0", "10.0.0.0/8"}
+
+func newClientIPApp(t *testing.T, header string, trusted []string) *fiber.App {
+	t.Helper()
+	clientIP, err := ClientIPMiddleware(header, trusted)
+	if err != nil {
+		t.Fatalf("ClientIPMiddleware: %v", err)
+	}
+	app := fiber.New()
+	app.Use(clientIP)
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(GetClientIP(c))
+	})
+	return app
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		xff     string
+		want    string
+	}{
+		{name: "no header", trusted: testProxies, want: "0.0.0.0"},
+		{name: "single hop", trusted: testProxies, xff: "203.0.113.7", want: "203.0.113.7"},
+		{name: "spoofed left-most", trusted: testProxies, xff: "1.2.3.4, 203.0.113.7", want: "203.0.113.7"},
+		{name: "inner proxies skipped", trusted: testProxies, xff: "1.2.3.4, 203.0.113.7, 10.0.0.2, 10.1.0.3", want: "203.0.113.7"},
+		{name: "spoofed trusted address", trusted: testProxies, xff: "10.0.0.9, 203.0.113.7", want: "203.0.113.7"},
+		{name: "all trusted", trusted: testProxies, xff: "10.0.0.2, 10.1.0.3", want: "10.0.0.2"},
+		{name: "malformed hop", trusted: testProxies, xff: "garbage, 10.0.0.2", want: "10.0.0.2"},
+		{name: "ipv4 mapped", trusted: testProxies, xff: "::ffff:203.0.113.7", want: "203.0.113.7"},
+		{name: "untrusted peer", trusted: []string{"10.0.0.0/8"}, xff: "203.0.113.7", want: "0.0.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newClientIPApp(t, fiber.HeaderXForwardedFor, tt.trusted)
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.xff != "" {
+				req.Header.Set(fiber.HeaderXForwardedFor, tt.xff)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if got := string(body); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPMiddlewareRejectsInvalidProxy(t *testing.T) {
+	if _, err := ClientIPMiddleware(fiber.HeaderXForwardedFor, []string{"10.0.0.0/33"}); err == nil {
+		t.Error("ClientIPMiddleware accepted an invalid CIDR")
+	}
+}
+
+// A client changing the left-most X-Forwarded-For value with every attempt
+// is still locked out by its real address
+func TestLoginLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfig{
+		Enabled:     true,
+		Passwords:   []config.PasswordItem{{Password: "secret"}},
+		TokenSecret: "test-secret",
+		TokenTTL:    time.Hour,
+		Lockout: config.LockoutConfig{
+			Enabled:     true,
+			MaxAttempts: 2,
+			BaseDelay:   time.Minute,
+			MaxDelay:    time.Hour,
+			ResetAfter:  time.Hour,
+		},
+	}}
+	logger := zerolog.Nop()
+	authService, err := service.NewAuthService(cfg, &logger)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+
+	app := newClientIPApp(t, fiber.HeaderXForwardedFor, testProxies)
+	app.Post("/login", NewContextHandler(&logger).Wrap(NewAuthHandler(authService).Login))
+
+	statuses := make([]int, 0, 4)
+	for _, spoofed := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3", "4.4.4.4"} {
+		req := httptest.NewRequest(fiber.MethodPost, "/login", strings.NewReader(`{"password":"wrong"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderXForwardedFor, spoofed+", 203.0.113.7")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Test: %v", err)
+		}
+		statuses = append(statuses, resp.StatusCode)
+	}
+
+	if last := statuses[len(statuses)-1]; last != fiber.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the last attempt locked out", statuses)
+	}
+}
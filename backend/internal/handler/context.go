@@ -30,12 +30,23 @@ func NewContextHandler(logger *zerolog.Logger) *ContextHandler {
 	}
 }
 
-// Wrap wraps a handler function with context
+// Wrap wraps a handler function with context.
+// The context logger is tagged with the request and trace IDs, route, client
+// IP and profile, and is also carried by UserContext for the service layer.
 func (h *ContextHandler) Wrap(fn Handler) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		logger := h.logger.With().
+			Str("request_id", GetRequestID(c)).
+			Str("trace_id", GetTraceID(c)).
+			Str("route", c.Route().Path).
+			Str("ip", GetClientIP(c)).
+			Str("profile", GetProfile(c).Name).
+			Logger()
+		c.SetUserContext(logger.WithContext(c.UserContext()))
+
 		ctx := &Context{
 			Ctx:    c,
-			Logger: h.logger,
+			Logger: &logger,
 			Resp:   &dto.Response{},
 		}
 		return fn(ctx)
@@ -60,18 +71,39 @@ func (ctx *Context) SuccessWithList(list interface{}) error {
 	return ctx.JSON(ctx.Resp.WithCode(code.Code).WithMessage(code.Message).WithList(list))
 }
 
-// BadRequest returns a bad request response
+// BadRequest returns a bad request response with HTTP status 400
 func (ctx *Context) BadRequest(msg string) error {
-	code := constants.InvalidParams
-	if msg != "" {
-		return ctx.JSON(ctx.Resp.WithCode(code.Code).WithMessage(msg))
-	}
-	return ctx.JSON(ctx.Resp.WithCode(code.Code).WithMessage(code.Message))
+	return writeError(ctx.Ctx, constants.InvalidParams, msg)
 }
 
-// InternalError returns an internal error response
+// Forbidden returns a forbidden response with HTTP status 403
+func (ctx *Context) Forbidden(msg string) error {
+	return writeError(ctx.Ctx, constants.Forbidden, msg)
+}
+
+// NotFound returns a not found response with HTTP status 404
+func (ctx *Context) NotFound(msg string) error {
+	return writeError(ctx.Ctx, constants.NotFound, msg)
+}
+
+// InternalError returns an internal error response with HTTP status 500
 func (ctx *Context) InternalError(err error) error {
-	code := constants.InternalError
 	ctx.Logger.Error().Err(err).Msg("internal error")
-	return ctx.JSON(ctx.Resp.WithCode(code.Code).WithMessage(code.Message))
+	return writeError(ctx.Ctx, constants.InternalError, "")
+}
+
+// Error returns the response of a domain error, see constants.Error.
+// Client errors carry the error message, server errors only the code's
+// message. Errors outside the domain are internal errors.
+func (ctx *Context) Error(err error) error {
+	code := constants.CodeOf(err)
+	switch {
+	case code == constants.InternalError:
+		return ctx.InternalError(err)
+	case code.Code >= fiber.StatusInternalServerError:
+		ctx.Logger.Warn().Err(err).Str("error_code", code.Error).Msg("upstream error")
+		return writeError(ctx.Ctx, code, "")
+	default:
+		return writeError(ctx.Ctx, code, err.Error())
+	}
 }
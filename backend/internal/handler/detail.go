@@ -29,7 +29,12 @@ func NewDetailHandler(service *service.DetailService) *DetailHandler {
 // @Param id query int true "Video ID"
 // @Success 200 {object} dto.DetailResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Failure 504 {object} dto.ErrorResponse
 // @Router /detail [get]
 func (h *DetailHandler) GetDetail(ctx *Context) error {
 	sourceCode := ctx.Query("source")
@@ -47,9 +52,9 @@ func (h *DetailHandler) GetDetail(ctx *Context) error {
 		return ctx.BadRequest("invalid id parameter")
 	}
 
-	detail, err := h.service.GetDetail(ctx.Context(), sourceCode, vodID)
+	detail, err := h.service.GetDetail(ctx.UserContext(), sourceCode, vodID, GetProfile(ctx.Ctx))
 	if err != nil {
-		return ctx.InternalError(err)
+		return ctx.Error(err)
 	}
 
 	return ctx.SuccessWithData(detail)
@@ -1,25 +1,84 @@
 package handler
 
 import (
+	"errors"
+	"strings"
+
 	"searchav/internal/constants"
 	"searchav/internal/dto"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
+// MIMEProblemJSON is the media type of RFC 7807 problem details
+const MIMEProblemJSON = "application/problem+json"
+
 // ErrorHandler handles global errors
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	code := constants.InternalError
+	code := constants.CodeOf(err)
+	msg := ""
 
 	// Handle Fiber errors
-	if e, ok := err.(*fiber.Error); ok {
-		if e.Code == fiber.StatusBadRequest {
-			code = constants.InvalidParams
-		}
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		code = fiberCode(e)
+		msg = e.Message
+	}
+
+	return writeError(c, code, msg)
+}
+
+// writeError answers with the HTTP status of a code and the message, or the
+// code's default message when empty. Clients accepting problem+json get
+// RFC 7807 problem details, others the unified response.
+func writeError(c *fiber.Ctx, code constants.Code, msg string) error {
+	if msg == "" {
+		msg = code.Message
+	}
+	c.Status(code.Code)
+
+	if c.Accepts(fiber.MIMEApplicationJSON, MIMEProblemJSON) == MIMEProblemJSON {
+		return c.JSON(&dto.Problem{
+			Type:     "about:blank",
+			Title:    utils.StatusMessage(code.Code),
+			Status:   code.Code,
+			Detail:   msg,
+			Instance: c.OriginalURL(),
+			Code:     code.Error,
+		}, MIMEProblemJSON)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(&dto.Response{
+	return c.JSON(&dto.Response{
 		Code:    code.Code,
-		Message: code.Message,
+		Error:   code.Error,
+		Message: msg,
 	})
 }
+
+// fiberCode maps a Fiber error to a response code. Statuses without a
+// dedicated code get an identifier derived from the status text.
+func fiberCode(e *fiber.Error) constants.Code {
+	switch e.Code {
+	case fiber.StatusBadRequest:
+		return constants.InvalidParams
+	case fiber.StatusUnauthorized:
+		return constants.Unauthorized
+	case fiber.StatusForbidden:
+		return constants.Forbidden
+	case fiber.StatusNotFound:
+		return constants.NotFound
+	case fiber.StatusTooManyRequests:
+		return constants.TooManyRequests
+	}
+	if e.Code >= fiber.StatusInternalServerError {
+		return constants.InternalError
+	}
+
+	text := strings.ToLower(utils.StatusMessage(e.Code))
+	return constants.Code{
+		Code:    e.Code,
+		Error:   strings.ReplaceAll(text, " ", "_"),
+		Message: text,
+	}
+}
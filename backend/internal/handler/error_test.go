@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"searchav/internal/constants"
+	"searchav/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// newErrorApp answers every route with an error, the way handlers do
+func newErrorApp() *fiber.App {
+	logger := zerolog.Nop()
+	wrap := NewContextHandler(&logger).Wrap
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/bad-request", wrap(func(ctx *Context) error {
+		return ctx.BadRequest("missing search keyword")
+	}))
+	app.Get("/unauthorized", func(c *fiber.Ctx) error {
+		return writeError(c, constants.Unauthorized, "")
+	})
+	app.Get("/source", wrap(func(ctx *Context) error {
+		return ctx.Error(fmt.Errorf("%w: abc", constants.ErrSourceNotFound))
+	}))
+	app.Get("/locked", func(c *fiber.Ctx) error {
+		return tooManyAttempts(c, &service.LockedOutError{RetryAfter: 1500 * time.Millisecond})
+	})
+	app.Get("/bad-payload", wrap(func(ctx *Context) error {
+		return ctx.Error(fmt.Errorf("%w: not an m3u8 playlist: https://cdn.example.com/1.m3u8", constants.ErrUpstreamBadPayload))
+	}))
+	app.Get("/timeout", wrap(func(ctx *Context) error {
+		return ctx.Error(fmt.Errorf("search source: %w", constants.ErrUpstreamTimeout))
+	}))
+	app.Get("/internal", wrap(func(ctx *Context) error {
+		return ctx.Error(fmt.Errorf("decode config: unexpected EOF"))
+	}))
+	app.Get("/fiber", func(c *fiber.Ctx) error {
+		return fiber.ErrUnauthorized
+	})
+	return app
+}
+
+func TestErrorResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		status     int
+		code       string
+		detail     string
+		retryAfter string
+	}{
+		{name: "bad request", path: "/bad-request", status: 400, code: "invalid_params", detail: "missing search keyword"},
+		{name: "unauthorized", path: "/unauthorized", status: 401, code: "unauthorized", detail: "unauthorized"},
+		{name: "fiber error", path: "/fiber", status: 401, code: "unauthorized", detail: "Unauthorized"},
+		{name: "domain not found", path: "/source", status: 404, code: "source_not_found", detail: "source not found: abc"},
+		{name: "unknown route", path: "/nope", status: 404, code: "not_found", detail: "Cannot GET /nope"},
+		{name: "locked out", path: "/locked", status: 429, code: "too_many_requests", detail: "too many failed attempts", retryAfter: "2"},
+		{name: "bad payload hides details", path: "/bad-payload", status: 502, code: "upstream_bad_payload", detail: "bad upstream payload"},
+		{name: "timeout", path: "/timeout", status: 504, code: "upstream_timeout", detail: "upstream timeout"},
+		{name: "internal hides details", path: "/internal", status: 500, code: "internal_error", detail: "internal server error"},
+	}
+
+	app := newErrorApp()
+	for _, tt := range tests {
+
+		t.Run(tt.name+"/json", func(t *testing.T) {
+			resp, body := getError(t, app, tt.path, fiber.MIMEApplicationJSON)
+			checkStatus(t, resp.StatusCode, tt.status, resp.Header.Get(fiber.HeaderRetryAfter), tt.retryAfter)
+			if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, fiber.MIMEApplicationJSON) {
+				t.Errorf("content type = %s, want json", ct)
+			}
+
+			var got struct {
+				Code  int    `json:"code"`
+				Error string `json:"error"`
+				Msg   string `json:"msg"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode %s: %v", body, err)
+			}
+			if got.Code != tt.status || got.Error != tt.code || got.Msg != tt.detail {
+				t.Errorf("body = %+v, want code %d, error %s, msg %q", got, tt.status, tt.code, tt.detail)
+			}
+		})
+
+		t.Run(tt.name+"/problem", func(t *testing.T) {
+			resp, body := getError(t, app, tt.path, MIMEProblemJSON)
+			checkStatus(t, resp.StatusCode, tt.status, resp.Header.Get(fiber.HeaderRetryAfter), tt.retryAfter)
+			if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, MIMEProblemJSON) {
+				t.Errorf("content type = %s, want %s", ct, MIMEProblemJSON)
+			}
+
+			var got struct {
+				Status   int    `json:"status"`
+				Code     string `json:"code"`
+				Detail   string `json:"detail"`
+				Instance string `json:"instance"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("decode %s: %v", body, err)
+			}
+			if got.Status != tt.status || got.Code != tt.code || got.Detail != tt.detail || got.Instance != tt.path {
+				t.Errorf("body = %+v, want status %d, code %s, detail %q, instance %s", got, tt.status, tt.code, tt.detail, tt.path)
+			}
+		})
+	}
+}
+
+// getError requests path accepting the given media type
+func getError(t *testing.T, app *fiber.App, path, accept string) (*http.Response, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	req.Header.Set(fiber.HeaderAccept, accept)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp, body
+}
+
+// checkStatus checks the status and Retry-After header of a response
+func checkStatus(t *testing.T, status, want int, retryAfter, wantRetryAfter string) {
+	t.Helper()
+	if status != want {
+		t.Errorf("status = %d, want %d", status, want)
+	}
+	if retryAfter != wantRetryAfter {
+		t.Errorf("Retry-After = %q, want %q", retryAfter, wantRetryAfter)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+
+	_ "searchav/internal/dto"
+	"searchav/internal/service"
+)
+
+// HLSHandler handles HLS playlist proxy requests
+type HLSHandler struct {
+	service *service.HLSService
+}
+
+// NewHLSHandler creates a new HLS handler
+func NewHLSHandler(service *service.HLSService) *HLSHandler {
+	return &HLSHandler{
+		service: service,
+	}
+}
+
+// Playlist handles ad-filtered playlist requests
+// @Summary Get ad-filtered HLS playlist
+// @Description Fetch an m3u8 playlist of a source, resolve master playlists, rewrite relative URIs to absolute and remove ad segments. The playlist must be on the source's API host or on a host of the play URLs it returned.
+// @Tags hls
+// @Produce application/vnd.apple.mpegurl
+// @Param source query string true "Source code the playlist belongs to"
+// @Param url query string true "Playlist URL"
+// @Success 200 {string} string "m3u8 playlist"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.ErrorResponse
+// @Failure 504 {object} dto.ErrorResponse
+// @Router /hls/playlist [get]
+func (h *HLSHandler) Playlist(ctx *Context) error {
+	sourceCode := ctx.Query("source")
+	if sourceCode == "" {
+		return ctx.BadRequest("missing source parameter")
+	}
+
+	playlistURL := ctx.Query("url")
+	if playlistURL == "" {
+		return ctx.BadRequest("missing url parameter")
+	}
+
+	playlist, err := h.service.CleanPlaylist(ctx.UserContext(), sourceCode, playlistURL, GetProfile(ctx.Ctx))
+	if errors.Is(err, service.ErrInvalidPlaylistURL) {
+		return ctx.BadRequest(err.Error())
+	}
+	if err != nil {
+		return ctx.Error(err)
+	}
+
+	ctx.Set("Content-Type", "application/vnd.apple.mpegurl")
+	ctx.Set("Cache-Control", "no-cache")
+	return ctx.SendString(playlist)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"searchav/internal/constants"
+	"searchav/internal/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler serves Prometheus metrics
+type MetricsHandler struct {
+	handler fiber.Handler
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(m *metrics.Metrics) *MetricsHandler {
+	return &MetricsHandler{
+		handler: adaptor.HTTPHandler(promhttp.HandlerFor(m.Registry(), promhttp.HandlerOpts{})),
+	}
+}
+
+// Metrics handles Prometheus scrapes
+func (h *MetricsHandler) Metrics(c *fiber.Ctx) error {
+	return h.handler(c)
+}
+
+// MetricsAuthMiddleware requires the metrics token as a bearer token.
+// It is separate from the API passwords so scrapers get no API access.
+func MetricsAuthMiddleware(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		got := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return writeError(c, constants.Unauthorized, "")
+		}
+		return c.Next()
+	}
+}
+
+// MetricsMiddleware observes the latency of every request by route and
+// status, for streamed responses until the stream ends
+func MetricsMiddleware(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		finish(c, c.Next())
+
+		// The matched route pattern keeps label cardinality bounded
+		method, route, status := c.Method(), c.Route().Path, strconv.Itoa(c.Response().StatusCode())
+		onResponseDone(c, func() {
+			m.HTTPRequest(method, route, status, time.Since(start))
+		})
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bufio"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RequestIDHeader is the header carrying the request ID
+	RequestIDHeader = fiber.HeaderXRequestID
+	// RequestIDKey is the context key for the request ID
+	RequestIDKey = "request_id"
+	// maxRequestIDLen bounds request IDs accepted from clients
+	maxRequestIDLen = 128
+	// responseDoneKey is the context key for the completion work of streamed responses
+	responseDoneKey = "response_done"
+)
+
+// RequestIDMiddleware propagates the X-Request-ID of the request, generating
+// one when it is missing or malformed, and echoes it in the response
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = utils.UUIDv4()
+		}
+
+		c.Locals(RequestIDKey, id)
+		c.Set(RequestIDHeader, id)
+		return c.Next()
+	}
+}
+
+// GetRequestID retrieves the request ID from context
+func GetRequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(RequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// AccessLogMiddleware emits one structured log line per request once the
+// response is complete, for streamed responses once the stream ends
+func AccessLogMiddleware(logger *zerolog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		finish(c, c.Next())
+
+		// The context is released before a stream ends, log a copy of its fields
+		event := logger.Info().
+			Str("request_id", GetRequestID(c)).
+			Str("trace_id", GetTraceID(c)).
+			Str("method", c.Method()).
+			Str("route", c.Route().Path).
+			Str("path", c.Path()).
+			Int("status", c.Response().StatusCode()).
+			Str("ip", GetClientIP(c)).
+			Str("profile", GetProfile(c).Name)
+		onResponseDone(c, func() {
+			event.Int64("duration_ms", time.Since(start).Milliseconds()).Msg("access")
+		})
+		return nil
+	}
+}
+
+// responseDone collects the work middlewares do once a streamed response is
+// complete
+type responseDone struct {
+	mu   sync.Mutex
+	done bool
+	fns  []func()
+}
+
+// run runs the collected work, and from then on any work added right away
+func (d *responseDone) run() {
+	d.mu.Lock()
+	d.done = true
+	fns := d.fns
+	d.fns = nil
+	d.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// add adds work to run once the response is complete
+func (d *responseDone) add(fn func()) {
+	d.mu.Lock()
+	if !d.done {
+		d.fns = append(d.fns, fn)
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+	fn()
+}
+
+// onResponseDone runs fn once the response is complete: right away, or for
+// responses streamed with streamBody once the stream ends. fn must not use c,
+// which is released before the stream ends.
+func onResponseDone(c *fiber.Ctx, fn func()) {
+	if d, ok := c.Locals(responseDoneKey).(*responseDone); ok {
+		d.add(fn)
+		return
+	}
+	fn()
+}
+
+// streamBody streams the response body with write. The stream runs after the
+// handler returns, so the work middlewares register with onResponseDone is
+// deferred until write returns.
+func streamBody(c *fiber.Ctx, write func(w *bufio.Writer)) {
+	d := &responseDone{}
+	c.Locals(responseDoneKey, d)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer d.run()
+		write(w)
+	})
+}
+
+// finish lets the error handler write the response for an error returned by
+// the rest of the chain, so middlewares observe the final status
+func finish(c *fiber.Ctx, err error) {
+	if err == nil {
+		return
+	}
+	if err := c.App().ErrorHandler(c, err); err != nil {
+		_ = c.SendStatus(fiber.StatusInternalServerError)
+	}
+}
+
+// validRequestID accepts short request IDs made of visible ASCII characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
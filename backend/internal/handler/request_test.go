@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// Streamed responses are logged once the stream ends, with its duration
+func TestAccessLogWaitsForStream(t *testing.T) {
+	const streamFor = 50 * time.Millisecond
+	tests := []struct {
+		name    string
+		handler fiber.Handler
+		minMs   int64
+	}{
+		{
+			name:    "buffered",
+			handler: func(c *fiber.Ctx) error { return c.SendString("ok") },
+		},
+		{
+			name: "streamed",
+			handler: func(c *fiber.Ctx) error {
+				streamBody(c, func(w *bufio.Writer) {
+					time.Sleep(streamFor)
+					_, _ = w.WriteString("ok")
+				})
+				return nil
+			},
+			minMs: streamFor.Milliseconds(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := zerolog.New(&out)
+			app := fiber.New()
+			app.Use(AccessLogMiddleware(&logger))
+			app.Get("/", tt.handler)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil), -1)
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+				t.Fatalf("body = %q, want ok", body)
+			}
+
+			var line struct {
+				Status     int   `json:"status"`
+				DurationMs int64 `json:"duration_ms"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("access log %q: %v", out.String(), err)
+			}
+			if line.Status != fiber.StatusOK || line.DurationMs < tt.minMs {
+				t.Errorf("access log = %+v, want status 200 and at least %dms", line, tt.minMs)
+			}
+		})
+	}
+}
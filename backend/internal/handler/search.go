@@ -1,7 +1,15 @@
 package handler
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
 	_ "searchav/internal/dto"
+	"searchav/internal/model"
 	"searchav/internal/service"
 )
 
@@ -25,8 +33,13 @@ func NewSearchHandler(service *service.SearchService) *SearchHandler {
 // @Produce json
 // @Param q query string true "Search keyword"
 // @Param adult query string false "Include adult sources (1=yes, 0=no, default=0)"
+// @Param sources query string false "Comma-separated source codes to search, default all"
+// @Param type query string false "Only results of this kind (movie, series, anime, variety, documentary) or category name"
+// @Param year query string false "Only results released in this year, e.g. 2023"
+// @Param area query string false "Only results from this area, e.g. 大陆"
 // @Success 200 {object} dto.SearchResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Router /search [get]
 func (h *SearchHandler) Search(ctx *Context) error {
 	keyword := ctx.Query("q")
@@ -34,6 +47,11 @@ func (h *SearchHandler) Search(ctx *Context) error {
 		return ctx.BadRequest("missing search keyword")
 	}
 
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		return ctx.BadRequest(err.Error())
+	}
+
 	// Check if user has adult permission from auth middleware
 	hasAdultPerm := GetAdultPerm(ctx.Ctx)
 
@@ -42,20 +60,150 @@ func (h *SearchHandler) Search(ctx *Context) error {
 	wantAdult := ctx.Query("adult") == "1"
 	includeAdult := hasAdultPerm && wantAdult
 
+	opts := service.SearchOptions{
+		IncludeAdult: includeAdult,
+		Sources:      parseSourceCodes(ctx.Query("sources")),
+		Profile:      GetProfile(ctx.Ctx),
+		Filter:       filter,
+	}
+
 	ctx.Logger.Info().
 		Str("keyword", keyword).
 		Bool("hasAdultPerm", hasAdultPerm).
 		Bool("wantAdult", wantAdult).
 		Bool("includeAdult", includeAdult).
+		Strs("sources", opts.Sources).
 		Msg("search request received")
 
-	list, err := h.service.Search(ctx.Context(), keyword, includeAdult)
+	result, err := h.service.Search(ctx.UserContext(), keyword, opts)
 	if err != nil {
-		ctx.Logger.Error().Err(err).Msg("search failed")
-		return ctx.InternalError(err)
+		return ctx.Error(err)
+	}
+
+	ctx.Logger.Info().Int("count", len(result.List)).Msg("search completed")
+
+	ctx.Resp.WithSources(result.Sources).WithFacets(result.Facets)
+	return ctx.SuccessWithList(result.List)
+}
+
+// SearchStream handles streamed video search requests
+// @Summary Search videos (streamed)
+// @Description Aggregate search across multiple video sources, pushing Server-Sent Events as each source responds.
+// @Description Events: "source" (per-source status), "results" (merged and ranked list so far, matching the filter, batched over 100ms),
+// @Description "done" (summary with facet counts).
+// @Tags search
+// @Produce text/event-stream
+// @Param q query string true "Search keyword"
+// @Param adult query string false "Include adult sources (1=yes, 0=no, default=0)"
+// @Param sources query string false "Comma-separated source codes to search, default all"
+// @Param type query string false "Only results of this kind (movie, series, anime, variety, documentary) or category name"
+// @Param year query string false "Only results released in this year, e.g. 2023"
+// @Param area query string false "Only results from this area, e.g. 大陆"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /search/stream [get]
+func (h *SearchHandler) SearchStream(ctx *Context) error {
+	keyword := ctx.Query("q")
+	if keyword == "" {
+		return ctx.BadRequest("missing search keyword")
 	}
 
-	ctx.Logger.Info().Int("count", len(list)).Msg("search completed")
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		return ctx.BadRequest(err.Error())
+	}
 
-	return ctx.SuccessWithList(list)
+	// Only allow adult content if user has permission AND requests it
+	opts := service.SearchOptions{
+		IncludeAdult: GetAdultPerm(ctx.Ctx) && ctx.Query("adult") == "1",
+		Sources:      parseSourceCodes(ctx.Query("sources")),
+		Profile:      GetProfile(ctx.Ctx),
+		Filter:       filter,
+	}
+
+	ctx.Logger.Info().
+		Str("keyword", keyword).
+		Bool("includeAdult", opts.IncludeAdult).
+		Strs("sources", opts.Sources).
+		Msg("stream search request received")
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	// The stream outlives the handler, keep only the request-scoped context.
+	// A failed write means the client is gone, cancel the source requests.
+	logger := ctx.Logger
+	reqCtx, cancel := context.WithCancel(ctx.UserContext())
+	streamBody(ctx.Ctx, func(w *bufio.Writer) {
+		defer cancel()
+		err := h.service.SearchStream(reqCtx, keyword, opts, func(ev model.SearchEvent) error {
+			err := writeEvent(w, ev)
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				cancel()
+			}
+			return err
+		})
+		if err != nil {
+			logger.Warn().Err(err).Msg("stream search aborted")
+		}
+	})
+
+	return nil
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload
+func writeEvent(w *bufio.Writer, ev model.SearchEvent) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
+
+// parseFilter parses the result filter of a search request
+func parseFilter(ctx *Context) (service.SearchFilter, error) {
+	filter := service.SearchFilter{
+		Type: strings.TrimSpace(ctx.Query("type")),
+		Year: strings.TrimSpace(ctx.Query("year")),
+		Area: strings.TrimSpace(ctx.Query("area")),
+	}
+	if filter.Year != "" && !isYear(filter.Year) {
+		return filter, errors.New("invalid year")
+	}
+	return filter, nil
+}
+
+// isYear reports whether a value is a four digit year
+func isYear(value string) bool {
+	if len(value) != 4 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSourceCodes parses a comma-separated source code list
+func parseSourceCodes(value string) []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes
 }
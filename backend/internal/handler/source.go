@@ -0,0 +1,43 @@
+package handler
+
+import (
+	_ "searchav/internal/dto"
+	"searchav/internal/service"
+)
+
+// SourceHandler handles source information requests
+type SourceHandler struct {
+	service *service.SourceService
+}
+
+// NewSourceHandler creates a new source handler
+func NewSourceHandler(service *service.SourceService) *SourceHandler {
+	return &SourceHandler{
+		service: service,
+	}
+}
+
+// List handles source list requests
+// @Summary List sources
+// @Description List configured sources with their enabled state and live health status.
+// @Description Only sources the caller's profile may use are listed.
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SourcesResponse
+// @Router /sources [get]
+func (h *SourceHandler) List(ctx *Context) error {
+	return ctx.SuccessWithList(h.service.List(GetProfile(ctx.Ctx)))
+}
+
+// Health handles source health requests
+// @Summary Get source health
+// @Description Get the health and circuit breaker state of each configured source
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.SourceHealthResponse
+// @Router /sources/health [get]
+func (h *SourceHandler) Health(ctx *Context) error {
+	return ctx.SuccessWithList(h.service.Health(GetProfile(ctx.Ctx)))
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("searchav/internal/handler")
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace of an incoming traceparent header, and carries it in UserContext so
+// the search fan-out and source requests become its children. Spans of
+// streamed responses end with the stream.
+func TracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier(http.Header(c.GetReqHeaders()))
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+				attribute.String("request.id", GetRequestID(c)),
+			),
+		)
+
+		c.SetUserContext(ctx)
+		finish(c, c.Next())
+
+		// The route is only known once the router matched the request
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		onResponseDone(c, func() { span.End() })
+		return nil
+	}
+}
+
+// GetTraceID returns the trace ID of the request span, empty when the
+// request is not sampled
+func GetTraceID(c *fiber.Ctx) string {
+	sc := trace.SpanContextFromContext(c.UserContext())
+	if !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,216 @@
+package hls
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FilterOptions tunes the ad detection heuristics
+type FilterOptions struct {
+	// MaxAdRunDuration is the longest discontinuity-bounded run, in seconds,
+	// that may still be treated as an ad break
+	MaxAdRunDuration float64
+}
+
+// DefaultFilterOptions are the filter options used when none are configured
+var DefaultFilterOptions = FilterOptions{
+	MaxAdRunDuration: 60,
+}
+
+// FilterResult reports what FilterAds removed
+type FilterResult struct {
+	Removed         int
+	RemovedDuration float64
+}
+
+// FilterAds removes ad segments from a media playlist in place.
+// Tags carrying state (keys, maps) of removed segments are moved to the next
+// kept segment, and a discontinuity is kept where an ad break was cut out.
+func FilterAds(p *MediaPlaylist, opts FilterOptions) FilterResult {
+	ads := DetectAds(p, opts)
+
+	var result FilterResult
+	for i, ad := range ads {
+		if ad {
+			result.Removed++
+			result.RemovedDuration += p.Segments[i].Duration
+		}
+	}
+
+	// Never strip the whole playlist
+	if result.Removed == 0 || result.Removed == len(p.Segments) {
+		return FilterResult{}
+	}
+
+	kept := make([]Segment, 0, len(p.Segments)-result.Removed)
+	var carried []string
+	discontinuity := false
+
+	for i, seg := range p.Segments {
+		if ads[i] {
+			for _, tag := range seg.Tags {
+				if isSegmentTag(tag) {
+					continue
+				}
+				carried = append(carried, tag)
+			}
+			discontinuity = discontinuity || seg.Discontinuity
+			continue
+		}
+
+		if discontinuity && !seg.Discontinuity && len(kept) > 0 {
+			carried = append(carried, tagDiscontinuity)
+			seg.Discontinuity = true
+		}
+		if len(carried) > 0 {
+			seg.Tags = append(carried, seg.Tags...)
+			carried = nil
+		}
+		discontinuity = false
+
+		kept = append(kept, seg)
+	}
+
+	p.Segments = kept
+	return result
+}
+
+// DetectAds reports for each segment whether it is considered an ad.
+// Two heuristics are combined:
+//   - path divergence: segments served from a different directory than the
+//     dominant one are ads
+//   - discontinuity runs: short runs between #EXT-X-DISCONTINUITY tags whose
+//     file naming differs from the dominant naming are ads
+func DetectAds(p *MediaPlaylist, opts FilterOptions) []bool {
+	ads := make([]bool, len(p.Segments))
+	detectByPath(p, ads)
+	detectByDiscontinuity(p, opts, ads)
+	return ads
+}
+
+// detectByPath marks segments outside the dominant base path.
+// The dominant path must cover most segments, otherwise the playlist is
+// assumed to be sharded across paths and nothing is marked.
+func detectByPath(p *MediaPlaylist, ads []bool) {
+	paths := make([]string, len(p.Segments))
+	counts := make(map[string]int)
+	for i, seg := range p.Segments {
+		paths[i] = basePath(seg.URI)
+		counts[paths[i]]++
+	}
+
+	if len(counts) <= 1 {
+		return
+	}
+
+	mainPath := dominant(paths, counts)
+	if counts[mainPath]*2 <= len(p.Segments) {
+		return
+	}
+
+	for i, path := range paths {
+		if path != mainPath {
+			ads[i] = true
+		}
+	}
+}
+
+// detectByDiscontinuity marks short discontinuity runs with foreign file naming
+func detectByDiscontinuity(p *MediaPlaylist, opts FilterOptions, ads []bool) {
+	if opts.MaxAdRunDuration <= 0 {
+		return
+	}
+
+	// Split segments into runs at each discontinuity
+	var runs [][]int
+	for i, seg := range p.Segments {
+		if i == 0 || seg.Discontinuity {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], i)
+	}
+
+	if len(runs) < 2 {
+		return
+	}
+
+	signatures := make([]string, len(p.Segments))
+	counts := make(map[string]int)
+	for i, seg := range p.Segments {
+		signatures[i] = nameSignature(seg.URI)
+		counts[signatures[i]]++
+	}
+	mainSignature := dominant(signatures, counts)
+
+	for _, run := range runs {
+		var duration float64
+		foreign := true
+		for _, i := range run {
+			duration += p.Segments[i].Duration
+			if signatures[i] == mainSignature {
+				foreign = false
+			}
+		}
+
+		if !foreign || duration > opts.MaxAdRunDuration {
+			continue
+		}
+		for _, i := range run {
+			ads[i] = true
+		}
+	}
+}
+
+// dominant returns the most frequent value, preferring the earliest on ties
+func dominant(values []string, counts map[string]int) string {
+	best := ""
+	for _, v := range values {
+		if best == "" || counts[v] > counts[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// basePath returns the directory of a segment URI
+// e.g.: https://example.com/video/20251218/abc/001.ts -> https://example.com/video/20251218/abc/
+func basePath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return path.Dir(uri) + "/"
+	}
+	return u.Scheme + "://" + u.Host + path.Dir(u.Path) + "/"
+}
+
+// nameSignature describes the naming scheme of a segment file,
+// e.g. "000123.ts" and "000124.ts" share the signature "ts:6:digits"
+func nameSignature(uri string) string {
+	name := uri
+	if u, err := url.Parse(uri); err == nil {
+		name = u.Path
+	}
+	name = path.Base(name)
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	class := "digits"
+	for _, r := range stem {
+		if r < '0' || r > '9' {
+			class = "mixed"
+			break
+		}
+	}
+
+	return strings.ToLower(ext) + ":" + strconv.Itoa(len(stem)) + ":" + class
+}
+
+// isSegmentTag reports whether a tag only describes its own segment
+func isSegmentTag(tag string) bool {
+	return strings.HasPrefix(tag, tagInf) ||
+		tag == tagDiscontinuity ||
+		strings.HasPrefix(tag, tagByteRange) ||
+		strings.HasPrefix(tag, tagDateTime)
+}
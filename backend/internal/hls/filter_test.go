@@ -0,0 +1,73 @@
+package hls
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the filter tests")
+
+func TestFilterAds(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		removed         int
+		removedDuration float64
+	}{
+		// No ads, nothing to detect
+		{fixture: "clean", removed: 0},
+		// A mid-roll break from another host between discontinuities
+		{fixture: "midroll", removed: 3, removedDuration: 15},
+		// Discontinuities between parts of the same video are not ads
+		{fixture: "discontinuity", removed: 0},
+		// A short foreign run is cut, a run longer than the maximum ad run is kept
+		{fixture: "longrun", removed: 3, removedDuration: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", tt.fixture+".m3u8"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := ParseMedia(string(input))
+			result := FilterAds(p, DefaultFilterOptions)
+			if result.Removed != tt.removed || result.RemovedDuration != tt.removedDuration {
+				t.Errorf("removed %d segments (%.1fs), want %d (%.1fs)",
+					result.Removed, result.RemovedDuration, tt.removed, tt.removedDuration)
+			}
+
+			golden := filepath.Join("testdata", tt.fixture+".golden.m3u8")
+			if *update {
+				if err := os.WriteFile(golden, []byte(p.String()), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != string(want) {
+				t.Errorf("filtered playlist =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDetectAdsDisabledRunCheck(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "longrun.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a maximum run duration only path divergence counts, and every
+	// segment of this playlist shares one directory
+	p := ParseMedia(string(input))
+	for i, ad := range DetectAds(p, FilterOptions{}) {
+		if ad {
+			t.Errorf("segment %d (%s) detected as an ad", i, p.Segments[i].URI)
+		}
+	}
+}
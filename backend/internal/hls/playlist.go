@@ -0,0 +1,247 @@
+package hls
+
+import (
+	"bufio"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagHeader        = "#EXTM3U"
+	tagStreamInf     = "#EXT-X-STREAM-INF"
+	tagInf           = "#EXTINF"
+	tagDiscontinuity = "#EXT-X-DISCONTINUITY"
+	tagByteRange     = "#EXT-X-BYTERANGE"
+	tagDateTime      = "#EXT-X-PROGRAM-DATE-TIME"
+)
+
+// globalTags are playlist-level tags kept in the header
+var globalTags = []string{
+	"#EXTM3U",
+	"#EXT-X-VERSION",
+	"#EXT-X-TARGETDURATION",
+	"#EXT-X-MEDIA-SEQUENCE",
+	"#EXT-X-DISCONTINUITY-SEQUENCE",
+	"#EXT-X-PLAYLIST-TYPE",
+	"#EXT-X-ALLOW-CACHE",
+	"#EXT-X-INDEPENDENT-SEGMENTS",
+	"#EXT-X-START",
+}
+
+// uriAttr matches URI="..." attributes in tags such as #EXT-X-KEY and #EXT-X-MAP
+var uriAttr = regexp.MustCompile(`URI="([^"]*)"`)
+
+// Variant is a stream variant of a master playlist
+type Variant struct {
+	URI       string
+	Bandwidth int
+}
+
+// Segment is a media segment together with the tag lines preceding it
+type Segment struct {
+	Tags          []string
+	URI           string
+	Duration      float64
+	Discontinuity bool
+}
+
+// MediaPlaylist is a parsed media playlist
+type MediaPlaylist struct {
+	Header   []string
+	Segments []Segment
+	Trailer  []string
+}
+
+// IsMaster reports whether the content is a master playlist
+func IsMaster(content string) bool {
+	return strings.Contains(content, tagStreamInf)
+}
+
+// IsPlaylist reports whether the content looks like an m3u8 playlist
+func IsPlaylist(content string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(strings.TrimSpace(content), "\ufeff"), tagHeader)
+}
+
+// ParseMaster parses the variants of a master playlist
+func ParseMaster(content string) []Variant {
+	var variants []Variant
+	var pending *Variant
+
+	for _, line := range splitLines(content) {
+		switch {
+		case strings.HasPrefix(line, tagStreamInf):
+			pending = &Variant{Bandwidth: attrInt(line, "BANDWIDTH")}
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case pending != nil:
+			pending.URI = line
+			variants = append(variants, *pending)
+			pending = nil
+		}
+	}
+
+	return variants
+}
+
+// BestVariant returns the variant with the highest bandwidth
+func BestVariant(variants []Variant) (Variant, bool) {
+	if len(variants) == 0 {
+		return Variant{}, false
+	}
+
+	best := variants[0]
+	for _, v := range variants[1:] {
+		if v.Bandwidth > best.Bandwidth {
+			best = v
+		}
+	}
+	return best, true
+}
+
+// ParseMedia parses a media playlist.
+// Playlist-level tags before the first segment go to Header, tags after the
+// last segment go to Trailer, everything else is attached to the next segment.
+func ParseMedia(content string) *MediaPlaylist {
+	p := &MediaPlaylist{}
+	var tags []string
+	inHeader := true
+
+	for _, line := range splitLines(content) {
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if inHeader && isGlobalTag(line) {
+				p.Header = append(p.Header, line)
+			} else {
+				tags = append(tags, line)
+			}
+			continue
+		}
+
+		inHeader = false
+		seg := Segment{Tags: tags, URI: line}
+		for _, tag := range tags {
+			switch {
+			case strings.HasPrefix(tag, tagInf+":"):
+				seg.Duration = parseDuration(tag)
+			case tag == tagDiscontinuity:
+				seg.Discontinuity = true
+			}
+		}
+		p.Segments = append(p.Segments, seg)
+		tags = nil
+	}
+
+	p.Trailer = tags
+	return p
+}
+
+// ResolveURIs rewrites relative segment, key and map URIs to absolute URLs
+func (p *MediaPlaylist) ResolveURIs(base *url.URL) {
+	for i := range p.Segments {
+		seg := &p.Segments[i]
+		seg.URI = ResolveURI(base, seg.URI)
+		for j, tag := range seg.Tags {
+			seg.Tags[j] = resolveTagURI(base, tag)
+		}
+	}
+	for i, tag := range p.Header {
+		p.Header[i] = resolveTagURI(base, tag)
+	}
+}
+
+// Duration returns the total duration of the playlist in seconds
+func (p *MediaPlaylist) Duration() float64 {
+	var total float64
+	for _, seg := range p.Segments {
+		total += seg.Duration
+	}
+	return total
+}
+
+// String renders the playlist
+func (p *MediaPlaylist) String() string {
+	var b strings.Builder
+
+	if len(p.Header) == 0 || p.Header[0] != tagHeader {
+		b.WriteString(tagHeader + "\n")
+	}
+	for _, line := range p.Header {
+		b.WriteString(line + "\n")
+	}
+	for _, seg := range p.Segments {
+		for _, tag := range seg.Tags {
+			b.WriteString(tag + "\n")
+		}
+		b.WriteString(seg.URI + "\n")
+	}
+	for _, line := range p.Trailer {
+		b.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// ResolveURI resolves a possibly relative URI against a base URL
+func ResolveURI(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveTagURI resolves the URI attribute of a tag line
+func resolveTagURI(base *url.URL, tag string) string {
+	return uriAttr.ReplaceAllStringFunc(tag, func(m string) string {
+		ref := uriAttr.FindStringSubmatch(m)[1]
+		return `URI="` + ResolveURI(base, ref) + `"`
+	})
+}
+
+// splitLines splits content into trimmed lines
+func splitLines(content string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")))
+	}
+	return lines
+}
+
+// isGlobalTag reports whether the tag is a playlist-level tag
+func isGlobalTag(line string) bool {
+	for _, tag := range globalTags {
+		if line == tag || strings.HasPrefix(line, tag+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDuration parses the duration of an #EXTINF tag
+func parseDuration(tag string) float64 {
+	value := strings.TrimPrefix(tag, tagInf+":")
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+	d, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return d
+}
+
+// attrInt returns an integer attribute of a tag line
+func attrInt(line, name string) int {
+	for _, attr := range strings.Split(line[strings.IndexByte(line, ':')+1:], ",") {
+		key, value, ok := strings.Cut(attr, "=")
+		if ok && strings.TrimSpace(key) == name {
+			n, _ := strconv.Atoi(strings.Trim(value, `" `))
+			return n
+		}
+	}
+	return 0
+}
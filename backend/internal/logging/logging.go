@@ -0,0 +1,16 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// FromContext returns the request-scoped logger carried by ctx, or fallback
+// when ctx has none
+func FromContext(ctx context.Context, fallback *zerolog.Logger) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return fallback
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "searchav"
+
+// Source request operations
+const (
+	OpSearch     = "search"
+	OpDetail     = "detail"
+	OpCategories = "categories"
+)
+
+// Metrics holds the Prometheus collectors of the service
+type Metrics struct {
+	registry *prometheus.Registry
+
+	sourceRequests *prometheus.CounterVec
+	sourceErrors   *prometheus.CounterVec
+	sourceLatency  *prometheus.HistogramVec
+	searchDuration prometheus.Histogram
+	searchResults  prometheus.Histogram
+	httpDuration   *prometheus.HistogramVec
+}
+
+// New creates the collectors and registers them with a dedicated registry,
+// along with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		sourceRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "source_requests_total",
+			Help:      "Requests to video sources, including ones skipped by an open circuit.",
+		}, []string{"source", "operation"}),
+		sourceErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "source_errors_total",
+			Help:      "Failed requests to video sources by error class.",
+		}, []string{"source", "operation", "class"}),
+		sourceLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "source_request_duration_seconds",
+			Help:      "Latency of requests sent to video sources.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2, 3, 5, 10},
+		}, []string{"source", "operation"}),
+		searchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "search_fanout_duration_seconds",
+			Help:      "Duration of aggregated search fan-outs until every source responded.",
+			Buckets:   []float64{.1, .25, .5, 1, 2, 3, 5, 10},
+		}),
+		searchResults: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "search_merged_results",
+			Help:      "Number of merged results returned by aggregated searches.",
+			Buckets:   []float64{0, 1, 5, 10, 25, 50, 100, 250},
+		}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.sourceRequests,
+		m.sourceErrors,
+		m.sourceLatency,
+		m.searchDuration,
+		m.searchResults,
+		m.httpDuration,
+	)
+	return m
+}
+
+// Registry returns the registry holding every collector
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// SourceRequest counts a source request, failed when class is not empty
+func (m *Metrics) SourceRequest(source, operation, class string) {
+	m.sourceRequests.WithLabelValues(source, operation).Inc()
+	if class != "" {
+		m.sourceErrors.WithLabelValues(source, operation, class).Inc()
+	}
+}
+
+// SourceLatency observes the latency of a request sent to a source
+func (m *Metrics) SourceLatency(source, operation string, d time.Duration) {
+	m.sourceLatency.WithLabelValues(source, operation).Observe(d.Seconds())
+}
+
+// Search observes the duration and merged result count of a search fan-out
+func (m *Metrics) Search(d time.Duration, merged int) {
+	m.searchDuration.Observe(d.Seconds())
+	m.searchResults.Observe(float64(merged))
+}
+
+// HTTPRequest observes the latency of an HTTP request
+func (m *Metrics) HTTPRequest(method, route, status string, d time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, status).Observe(d.Seconds())
+}
+
+// CounterFunc registers a counter whose value is read from fn on every scrape.
+// It exposes counters kept by other components, such as cache hits.
+func (m *Metrics) CounterFunc(name, help string, labels prometheus.Labels, fn func() float64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, fn))
+}
+
+// GaugeFunc registers a gauge whose value is read from fn on every scrape
+func (m *Metrics) GaugeFunc(name, help string, labels prometheus.Labels, fn func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, fn))
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LoginResult is a session token issued for a valid password
+type LoginResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Profile   string    `json:"profile,omitempty"`
+	Adult     bool      `json:"adult"`
+	Admin     bool      `json:"admin"`
+}
@@ -0,0 +1,77 @@
+package model
+
+// Search stream event types
+const (
+	SearchEventSource  = "source"
+	SearchEventResults = "results"
+	SearchEventDone    = "done"
+)
+
+// SearchEvent is an event emitted while an aggregated search is in progress
+type SearchEvent struct {
+	Type string
+	Data interface{}
+}
+
+// Source request statuses
+const (
+	SourceStatusOK          = "ok"
+	SourceStatusEmpty       = "empty"
+	SourceStatusTimeout     = "timeout"
+	SourceStatusHTTPError   = "http_error"
+	SourceStatusDecodeError = "decode_error"
+	SourceStatusCircuitOpen = "circuit_open"
+	SourceStatusError       = "error"
+)
+
+// SourceStatus describes the outcome of a single source request
+type SourceStatus struct {
+	SourceCode string `json:"source_code"`
+	SourceName string `json:"source_name"`
+	Status     string `json:"status"`
+	Count      int    `json:"count"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SearchResult is the result of an aggregated search
+type SearchResult struct {
+	List    []VideoItem
+	Sources []SourceStatus
+	Facets  *SearchFacets
+}
+
+// SearchFacets counts search results by attribute. Each facet counts the
+// results matching the filters on the other attributes, so counts show what
+// changing that filter would return.
+type SearchFacets struct {
+	Type []FacetCount `json:"type"`
+	Year []FacetCount `json:"year"`
+	Area []FacetCount `json:"area"`
+}
+
+// FacetCount is the number of results with an attribute value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchSummary summarizes a finished aggregated search
+type SearchSummary struct {
+	Sources    int           `json:"sources"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Total      int           `json:"total"` // Results matching the filter
+	DurationMs int64         `json:"duration_ms"`
+	Facets     *SearchFacets `json:"facets,omitempty"`
+}
+
+// SourceListItem is a configured source as listed to clients
+type SourceListItem struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Adult        bool   `json:"adult"`
+	Enabled      bool   `json:"enabled"`
+	Status       string `json:"status"`
+	AvgLatencyMs int64  `json:"avg_latency_ms"`
+}
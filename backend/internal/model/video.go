@@ -6,6 +6,9 @@ type VideoItem struct {
 	VodPic     string       `json:"vod_pic"`
 	VodRemarks string       `json:"vod_remarks,omitempty"`
 	TypeName   string       `json:"type_name,omitempty"`
+	Kind       string       `json:"kind,omitempty"`     // Coarse kind: movie, series, anime, variety or documentary
+	VodYear    string       `json:"vod_year,omitempty"` // Release year such as "2023"
+	VodArea    string       `json:"vod_area,omitempty"`
 	Sources    []SourceInfo `json:"sources"`
 }
 
@@ -14,16 +17,42 @@ type SourceInfo struct {
 	SourceCode string `json:"source_code"`
 	SourceName string `json:"source_name"`
 	VodID      int    `json:"vod_id"`
+	VodName    string `json:"vod_name,omitempty"` // Title as listed by the source
+	Variant    string `json:"variant,omitempty"`  // Variant label such as "国语" or "第2季", empty for the plain title
 }
 
 // VideoDetail represents video detail information
 type VideoDetail struct {
-	VodName     string   `json:"vod_name"`
-	VodPic      string   `json:"vod_pic"`
-	VodContent  string   `json:"vod_content,omitempty"`
-	VodYear     string   `json:"vod_year,omitempty"`
-	VodArea     string   `json:"vod_area,omitempty"`
-	VodDirector string   `json:"vod_director,omitempty"`
-	VodActor    string   `json:"vod_actor,omitempty"`
-	Episodes    []string `json:"episodes"`
+	VodName     string     `json:"vod_name"`
+	VodPic      string     `json:"vod_pic"`
+	VodContent  string     `json:"vod_content,omitempty"`
+	VodYear     string     `json:"vod_year,omitempty"`
+	VodArea     string     `json:"vod_area,omitempty"`
+	VodDirector string     `json:"vod_director,omitempty"`
+	VodActor    string     `json:"vod_actor,omitempty"`
+	Lines       []PlayLine `json:"lines"`
+
+	// Episodes is the flat URL list of the preferred line.
+	// Deprecated: kept for compatibility with older clients, use Lines.
+	Episodes []string `json:"episodes"`
+}
+
+// Play line formats
+const (
+	PlayFormatM3U8  = "m3u8"
+	PlayFormatMP4   = "mp4"
+	PlayFormatShare = "share"
+)
+
+// PlayLine is a play line (playback source) of a video
+type PlayLine struct {
+	Name     string    `json:"name"`
+	Format   string    `json:"format"`
+	Episodes []Episode `json:"episodes"`
+}
+
+// Episode is a single playable episode of a play line
+type Episode struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
 }
@@ -0,0 +1,35 @@
+package ranking
+
+import (
+	"math"
+	"strings"
+)
+
+// Exact ranks exact matches over prefix matches over other substring
+// matches, ignoring every other signal
+type Exact struct{}
+
+// Score implements Scorer
+func (Exact) Score(q Query, c Candidate) float64 {
+	name := newText(c.Title).key
+
+	best := 0.0
+	for _, keyword := range q.Keywords {
+		kw := newText(keyword).key
+		if kw == "" {
+			continue
+		}
+
+		idx := strings.Index(name, kw)
+		switch {
+		case name == kw:
+			return 1
+		case idx == 0:
+			best = math.Max(best, 0.8)
+		case idx > 0:
+			// Earlier matches rank higher, staying below prefix matches
+			best = math.Max(best, 0.6-0.2*float64(idx)/float64(len(name)))
+		}
+	}
+	return best
+}
@@ -0,0 +1,93 @@
+package ranking
+
+import (
+	"fmt"
+	"sort"
+
+	"searchav/internal/config"
+)
+
+// Scorer names, matched against search.ranking.scorer
+const (
+	ScorerWeighted = "weighted"
+	ScorerExact    = "exact"
+
+	// DefaultScorer is used when no scorer is configured
+	DefaultScorer = ScorerWeighted
+)
+
+// Query is what a search ranks its results against
+type Query struct {
+	Keywords []string // Keyword variants searched for, the best matching one counts
+	Year     int      // Year given in the keyword, 0 when none
+	Sources  int      // Number of sources searched
+}
+
+// Candidate is a merged search result being ranked
+type Candidate struct {
+	Title   string
+	Year    int      // 0 when unknown
+	Sources []string // Codes of the sources listing the title
+}
+
+// Scorer scores how well a candidate matches a query, higher is better
+type Scorer interface {
+	Score(q Query, c Candidate) float64
+}
+
+// Factory creates a scorer from the ranking configuration
+type Factory func(cfg config.RankingConfig) Scorer
+
+var factories = map[string]Factory{
+	ScorerWeighted: func(cfg config.RankingConfig) Scorer { return NewWeighted(cfg) },
+	ScorerExact:    func(config.RankingConfig) Scorer { return Exact{} },
+}
+
+// Register registers a scorer factory, replacing any existing one
+func Register(name string, f Factory) {
+	factories[name] = f
+}
+
+// New creates the scorer named by the configuration
+func New(cfg config.RankingConfig) (Scorer, error) {
+	name := cfg.Scorer
+	if name == "" {
+		name = DefaultScorer
+	}
+
+	f, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown search.ranking.scorer %q", name)
+	}
+	return f(cfg), nil
+}
+
+// Sort sorts candidates by descending score. Ties keep more sources first,
+// then shorter titles. The order of items follows the candidates.
+func Sort[T any](scorer Scorer, q Query, items []T, candidate func(T) Candidate) {
+	type scored struct {
+		item  T
+		score float64
+		c     Candidate
+	}
+
+	list := make([]scored, len(items))
+	for i, item := range items {
+		c := candidate(item)
+		list[i] = scored{item: item, score: scorer.Score(q, c), c: c}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		if len(list[i].c.Sources) != len(list[j].c.Sources) {
+			return len(list[i].c.Sources) > len(list[j].c.Sources)
+		}
+		return len([]rune(list[i].c.Title)) < len([]rune(list[j].c.Title))
+	})
+
+	for i := range list {
+		items[i] = list[i].item
+	}
+}
@@ -0,0 +1,102 @@
+package ranking
+
+import (
+	"strconv"
+
+	"searchav/internal/title"
+)
+
+// text is a title or keyword prepared for comparison
+type text struct {
+	key    string   // Normalized title with its season, see title.Normalize
+	runes  []rune   // Runes of key
+	tokens []string // See title.Tokens
+}
+
+// newText prepares a title for comparison. Language and quality labels are
+// ignored but seasons are kept, so a season does not rank as the plain title.
+func newText(s string) text {
+	t := title.Normalize(s)
+	key := t.Key
+	if t.Season > 0 {
+		key += "s" + strconv.Itoa(t.Season)
+	}
+	return text{
+		key:    key,
+		runes:  []rune(key),
+		tokens: title.Tokens(s),
+	}
+}
+
+// similarity rates how close a title is to the keyword t by edit distance.
+// Titles containing the keyword rate at least 0.5, more the larger the part
+// of the title the keyword covers.
+func (t text) similarity(name text) float64 {
+	longest := max(len(t.runes), len(name.runes))
+	if longest == 0 {
+		return 0
+	}
+
+	sim := 1 - float64(levenshtein(t.runes, name.runes))/float64(longest)
+	if len(t.runes) > 0 && containsRunes(name.runes, t.runes) {
+		sim = max(sim, 0.5+0.5*float64(len(t.runes))/float64(len(name.runes)))
+	}
+	return sim
+}
+
+// coverage is the share of the keyword's tokens found in the title
+func (t text) coverage(name text) float64 {
+	if len(t.tokens) == 0 {
+		return 0
+	}
+
+	have := make(map[string]bool, len(name.tokens))
+	for _, token := range name.tokens {
+		have[token] = true
+	}
+	found := 0
+	for _, token := range t.tokens {
+		if have[token] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(t.tokens))
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func containsRunes(s, sub []rune) bool {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
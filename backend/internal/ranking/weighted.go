@@ -0,0 +1,97 @@
+package ranking
+
+import (
+	"math"
+	"time"
+
+	"searchav/internal/config"
+)
+
+// yearSpan is the year difference at which year proximity reaches 0
+const yearSpan = 20
+
+// DefaultWeights are used when no weight is configured
+var DefaultWeights = config.RankingWeights{
+	Similarity: 0.5,
+	Tokens:     0.2,
+	Sources:    0.15,
+	Year:       0.05,
+	Quality:    0.1,
+}
+
+// Weighted combines several relevance signals, each in [0, 1], with the
+// weights configured in search.ranking.weights
+type Weighted struct {
+	weights       config.RankingWeights
+	sourceWeights map[string]float64
+	now           func() time.Time
+}
+
+// NewWeighted creates a weighted scorer
+func NewWeighted(cfg config.RankingConfig) *Weighted {
+	weights := cfg.Weights
+	if weights == (config.RankingWeights{}) {
+		weights = DefaultWeights
+	}
+	return &Weighted{
+		weights:       weights,
+		sourceWeights: cfg.SourceWeights,
+		now:           time.Now,
+	}
+}
+
+// Score implements Scorer
+func (w *Weighted) Score(q Query, c Candidate) float64 {
+	name := newText(c.Title)
+
+	// Text signals use the best matching keyword
+	var similarity, tokens float64
+	for _, keyword := range q.Keywords {
+		kw := newText(keyword)
+		similarity = math.Max(similarity, kw.similarity(name))
+		tokens = math.Max(tokens, kw.coverage(name))
+	}
+
+	return w.weights.Similarity*similarity +
+		w.weights.Tokens*tokens +
+		w.weights.Sources*agreement(q, c) +
+		w.weights.Year*w.yearProximity(q, c) +
+		w.weights.Quality*w.quality(c)
+}
+
+// agreement is the share of searched sources listing the title
+func agreement(q Query, c Candidate) float64 {
+	if q.Sources == 0 {
+		return 0
+	}
+	return math.Min(float64(len(c.Sources))/float64(q.Sources), 1)
+}
+
+// yearProximity rates how close the title's year is to the year given in the
+// keyword, or to the current year so newer titles come first. Unknown years
+// are neutral.
+func (w *Weighted) yearProximity(q Query, c Candidate) float64 {
+	if c.Year == 0 {
+		return 0.5
+	}
+	target := q.Year
+	if target == 0 {
+		target = w.now().Year()
+	}
+	diff := math.Abs(float64(c.Year - target))
+	return math.Max(1-diff/yearSpan, 0)
+}
+
+// quality is the best quality weight of the sources listing the title,
+// sources without a configured weight count as 1
+func (w *Weighted) quality(c Candidate) float64 {
+	best := 0.0
+	for _, code := range c.Sources {
+		weight, ok := w.sourceWeights[code]
+		if !ok {
+			weight = 1
+		}
+		best = math.Max(best, weight)
+	}
+	return best
+}
@@ -0,0 +1,314 @@
+package ranking
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+)
+
+// newTestWeighted creates a weighted scorer with the default weights whose
+// current year is 2024
+func newTestWeighted(sourceWeights map[string]float64) *Weighted {
+	w := NewWeighted(config.RankingConfig{SourceWeights: sourceWeights})
+	w.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+	return w
+}
+
+func TestWeightedOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         Query
+		sourceWeights map[string]float64
+		candidates    []Candidate // In the expected order
+	}{
+		{
+			name:  "exact title beats fuzzy match",
+			query: Query{Keywords: []string{"进击的巨人"}, Sources: 3},
+			candidates: []Candidate{
+				{Title: "进击的巨人", Year: 2013, Sources: []string{"a"}},
+				{Title: "进击的巨人 最终季", Year: 2013, Sources: []string{"a", "b"}},
+				{Title: "巨人的进击", Year: 2013, Sources: []string{"a", "b", "c"}},
+			},
+		},
+		{
+			name:  "exact title beats longer titles containing it",
+			query: Query{Keywords: []string{"海贼王"}, Sources: 2},
+			candidates: []Candidate{
+				{Title: "海贼王", Sources: []string{"a"}},
+				{Title: "海贼王 剧场版 红发歌姬", Sources: []string{"a", "b"}},
+				{Title: "海贼", Sources: []string{"a", "b"}},
+			},
+		},
+		{
+			name:  "best keyword variant counts",
+			query: Query{Keywords: []string{"海贼王", "海賊王"}, Sources: 1},
+			candidates: []Candidate{
+				{Title: "海賊王", Sources: []string{"a"}},
+				{Title: "海贼", Sources: []string{"a"}},
+			},
+		},
+		{
+			name:  "latin titles ignore case and punctuation",
+			query: Query{Keywords: []string{"spider man"}, Sources: 1},
+			candidates: []Candidate{
+				{Title: "Spider-Man", Sources: []string{"a"}},
+				{Title: "Spider-Man: No Way Home", Sources: []string{"a"}},
+				{Title: "Iron Man", Sources: []string{"a"}},
+			},
+		},
+		{
+			name:  "year closest to the query year",
+			query: Query{Keywords: []string{"沙丘"}, Year: 1984, Sources: 1},
+			candidates: []Candidate{
+				{Title: "沙丘", Year: 1984, Sources: []string{"a"}},
+				{Title: "沙丘", Year: 2000, Sources: []string{"a"}},
+				{Title: "沙丘", Year: 2021, Sources: []string{"a"}},
+			},
+		},
+		{
+			name:  "newer titles without a query year",
+			query: Query{Keywords: []string{"沙丘"}, Sources: 1},
+			candidates: []Candidate{
+				{Title: "沙丘", Year: 2021, Sources: []string{"a"}},
+				{Title: "沙丘", Year: 2010, Sources: []string{"a"}},
+				{Title: "沙丘", Year: 2000, Sources: []string{"a"}},
+			},
+		},
+		{
+			name:  "unknown year between near and far years",
+			query: Query{Keywords: []string{"沙丘"}, Year: 2021, Sources: 1},
+			candidates: []Candidate{
+				{Title: "沙丘", Year: 2021, Sources: []string{"a"}},
+				{Title: "沙丘", Sources: []string{"a"}},
+				{Title: "沙丘", Year: 1984, Sources: []string{"a"}},
+			},
+		},
+		{
+			name:  "source agreement",
+			query: Query{Keywords: []string{"三体"}, Sources: 4},
+			candidates: []Candidate{
+				{Title: "三体", Year: 2023, Sources: []string{"a", "b", "c", "d"}},
+				{Title: "三体", Year: 2023, Sources: []string{"a", "b"}},
+				{Title: "三体", Year: 2023, Sources: []string{"a"}},
+			},
+		},
+		{
+			name:          "per-source weights, unset weights count as 1",
+			query:         Query{Keywords: []string{"三体"}, Sources: 3},
+			sourceWeights: map[string]float64{"fair": 0.6, "poor": 0.2, "bad": 0},
+			candidates: []Candidate{
+				{Title: "三体", Year: 2023, Sources: []string{"unset"}},
+				{Title: "三体", Year: 2023, Sources: []string{"fair"}},
+				{Title: "三体", Year: 2023, Sources: []string{"poor"}},
+				{Title: "三体", Year: 2023, Sources: []string{"bad"}},
+			},
+		},
+		{
+			name:          "best source weight of a merged title",
+			query:         Query{Keywords: []string{"三体"}, Sources: 3},
+			sourceWeights: map[string]float64{"poor": 0.2, "poor2": 0.3},
+			candidates: []Candidate{
+				{Title: "三体", Year: 2023, Sources: []string{"poor", "good"}},
+				{Title: "三体", Year: 2023, Sources: []string{"poor", "poor2"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWeighted(tt.sourceWeights)
+
+			// Scores must decrease strictly, so the order does not rest on
+			// the tie-breaks of Sort
+			for i := 1; i < len(tt.candidates); i++ {
+				prev, cur := w.Score(tt.query, tt.candidates[i-1]), w.Score(tt.query, tt.candidates[i])
+				if prev <= cur {
+					t.Errorf("score(%v) = %.4f, not above score(%v) = %.4f",
+						tt.candidates[i-1], prev, tt.candidates[i], cur)
+				}
+			}
+
+			// Sorting the reversed fixture restores it
+			items := make([]int, len(tt.candidates))
+			for i := range items {
+				items[i] = len(items) - 1 - i
+			}
+			Sort(w, tt.query, items, func(i int) Candidate { return tt.candidates[i] })
+			for i, got := range items {
+				if got != i {
+					t.Fatalf("sorted order = %v, want fixture order", items)
+				}
+			}
+		})
+	}
+}
+
+func TestNewWeightedDefaults(t *testing.T) {
+	if w := NewWeighted(config.RankingConfig{}); w.weights != DefaultWeights {
+		t.Errorf("weights = %+v, want defaults", w.weights)
+	}
+
+	custom := config.RankingWeights{Similarity: 1}
+	if w := NewWeighted(config.RankingConfig{Weights: custom}); w.weights != custom {
+		t.Errorf("weights = %+v, want %+v", w.weights, custom)
+	}
+}
+
+// fixture is the response of every source searched for a query, in the
+// MacCMS format sources answer in
+type fixture struct {
+	Keywords  []string `json:"keywords"`
+	Year      int      `json:"year"`
+	Responses map[string]struct {
+		List []struct {
+			VodName string `json:"vod_name"`
+			VodYear string `json:"vod_year"`
+		} `json:"list"`
+	} `json:"responses"`
+}
+
+// loadFixture loads testdata/<name>.json as a query and its candidates, the
+// titles listed with the same name and year by several sources merged
+func loadFixture(t testing.TB, name string) (Query, []Candidate) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	codes := make([]string, 0, len(f.Responses))
+	for code := range f.Responses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	var candidates []Candidate
+	index := make(map[string]int)
+	for _, code := range codes {
+		for _, v := range f.Responses[code].List {
+			key := candidateKey(v.VodName, v.VodYear)
+			i, ok := index[key]
+			if !ok {
+				year, _ := strconv.Atoi(v.VodYear)
+				i = len(candidates)
+				index[key] = i
+				candidates = append(candidates, Candidate{Title: v.VodName, Year: year})
+			}
+			candidates[i].Sources = append(candidates[i].Sources, code)
+		}
+	}
+
+	return Query{Keywords: f.Keywords, Year: f.Year, Sources: len(codes)}, candidates
+}
+
+func candidateKey(title, year string) string {
+	if year == "" {
+		return title
+	}
+	return title + " (" + year + ")"
+}
+
+// The source responses of testdata sort in the expected order
+func TestWeightedFixtureOrder(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []string // Titles with their year, best first
+	}{
+		{
+			fixture: "attack_on_titan",
+			want: []string{
+				"进击的巨人 (2013)",
+				"进击的巨人 最终季 (2020)",
+				"进击的巨人 第三季 (2018)",
+				"进击的巨人 第二季 (2017)",
+				"进击的巨人真人版 (2015)",
+				"进击的巨人中学校 (2014)",
+				"进击的巨人剧场版：红莲的弓矢 (2014)",
+				"巨人的进击 (2019)",
+			},
+		},
+		{
+			fixture: "one_piece",
+			want: []string{
+				"海贼王 (1999)",
+				"海賊王 (1999)",
+				"海贼王 真人版 (2023)",
+				"海贼王剧场版：红发歌姬 (2022)",
+				"海贼王：狂热行动 (2019)",
+				"海贼猎人 (2017)",
+				"海贼战队豪快者 (2011)",
+			},
+		},
+		{
+			fixture: "dune_2021",
+			want: []string{
+				"沙丘 (2021)",
+				"沙丘 (1984)",
+				"沙丘2 (2024)",
+				"沙丘：预言 (2024)",
+				"沙丘之子 (2003)",
+			},
+		},
+		{
+			fixture: "spider_man",
+			want: []string{
+				"Spider-Man (2002)",
+				"Spider-Man 2 (2004)",
+				"Spider-Man: No Way Home (2021)",
+				"The Amazing Spider-Man (2012)",
+				"Iron Man (2008)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			q, candidates := loadFixture(t, tt.fixture)
+
+			// Start from the reverse order, so sorting does not just keep
+			// the order of the responses
+			slices.Reverse(candidates)
+			Sort(newTestWeighted(nil), q, candidates, func(c Candidate) Candidate { return c })
+
+			got := make([]string, 0, len(candidates))
+			for _, c := range candidates {
+				got = append(got, candidateKey(c.Title, strconv.Itoa(c.Year)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+// rankingFixtures are the result sets of testdata
+var rankingFixtures = []string{"attack_on_titan", "one_piece", "dune_2021", "spider_man"}
+
+func BenchmarkWeighted(b *testing.B) {
+	w := newTestWeighted(nil)
+	for _, name := range rankingFixtures {
+		b.Run(name, func(b *testing.B) {
+			q, candidates := loadFixture(b, name)
+			items := make([]int, len(candidates))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := range items {
+					items[j] = j
+				}
+				Sort(w, q, items, func(j int) Candidate { return candidates[j] })
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"searchav/internal/config"
+	"searchav/internal/constants"
+	"searchav/internal/logging"
+	"searchav/internal/source"
+	"searchav/internal/store"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// probeKeyword is searched to check that a source endpoint works
+	probeKeyword = "test"
+)
+
+var (
+	// ErrSourceExists is returned when creating a source whose code is taken
+	ErrSourceExists = errors.New("source already exists")
+	// ErrSourceNotFound is returned when a source code is unknown
+	ErrSourceNotFound = constants.ErrSourceNotFound
+	// ErrInvalidSource is returned when a source fails validation
+	ErrInvalidSource = errors.New("invalid source")
+)
+
+// AdminService manages sources at runtime.
+// Changes are validated, persisted to the source store and swapped into the
+// live configuration without a restart.
+type AdminService struct {
+	mu       sync.Mutex
+	config   *config.Config
+	client   *source.Client
+	provider source.Provider
+	store    *store.SourceStore
+	logger   *zerolog.Logger
+}
+
+// NewAdminService creates a new admin service.
+// Sources previously saved in the store replace the configured ones.
+func NewAdminService(cfg *config.Config, client *source.Client, provider source.Provider, store *store.SourceStore, logger *zerolog.Logger) (*AdminService, error) {
+	sources, ok, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := cfg.SetManagedSources(sources); err != nil {
+			return nil, fmt.Errorf("invalid sources store: %w", err)
+		}
+		logger.Info().Int("sources", len(sources)).Msg("sources loaded from store")
+	}
+
+	return &AdminService{
+		config:   cfg,
+		client:   client,
+		provider: provider,
+		store:    store,
+		logger:   logger,
+	}, nil
+}
+
+// ListSources returns all sources including disabled ones
+func (s *AdminService) ListSources() []config.SourceItem {
+	return s.config.GetSources()
+}
+
+// CreateSource validates and adds a new source
+func (s *AdminService) CreateSource(ctx context.Context, item config.SourceItem) error {
+	if err := s.validate(ctx, item); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := s.config.GetSources()
+	for _, src := range sources {
+		if src.Code == item.Code {
+			return fmt.Errorf("%w: %s", ErrSourceExists, item.Code)
+		}
+	}
+
+	return s.apply(append(sources, item), "created", item.Code)
+}
+
+// UpdateSource validates and replaces the source with the given code
+func (s *AdminService) UpdateSource(ctx context.Context, code string, item config.SourceItem) error {
+	item.Code = code
+	if err := s.validate(ctx, item); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := s.config.GetSources()
+	for i, src := range sources {
+		if src.Code == code {
+			sources[i] = item
+			if err := s.apply(sources, "updated", code); err != nil {
+				return err
+			}
+			s.Forget(code)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrSourceNotFound, code)
+}
+
+// DeleteSource removes the source with the given code
+func (s *AdminService) DeleteSource(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := s.config.GetSources()
+	for i, src := range sources {
+		if src.Code == code {
+			if err := s.apply(append(sources[:i], sources[i+1:]...), "deleted", code); err != nil {
+				return err
+			}
+			s.Forget(code)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrSourceNotFound, code)
+}
+
+// apply persists the source list and swaps it into the live configuration.
+// Caller holds s.mu.
+func (s *AdminService) apply(sources []config.SourceItem, action, code string) error {
+	if err := config.ValidateSources(sources); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
+	if err := s.store.Save(sources); err != nil {
+		return err
+	}
+	if err := s.config.SetManagedSources(sources); err != nil {
+		return err
+	}
+
+	s.logger.Info().Str("source", code).Str("action", action).Int("sources", len(sources)).Msg("sources changed")
+	return nil
+}
+
+// Forget drops the cached responses and health of a changed or removed
+// source, so nothing from its previous endpoint is served or counted
+func (s *AdminService) Forget(code string) {
+	if cached, ok := s.provider.(*source.CachedProvider); ok {
+		cached.Invalidate(code)
+	}
+	s.client.ResetHealth(code)
+}
+
+// validate checks the source fields and, for enabled sources, sends a test
+// query to the endpoint
+func (s *AdminService) validate(ctx context.Context, item config.SourceItem) error {
+	if item.Code == "" || item.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidSource)
+	}
+
+	u, err := url.Parse(item.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidSource)
+	}
+
+	if !s.client.Supports(item.Type) {
+		return fmt.Errorf("%w: unsupported type %q", ErrInvalidSource, item.Type)
+	}
+
+	if !item.Enabled {
+		return nil
+	}
+
+	if _, err := s.client.Probe(ctx, item, probeKeyword); err != nil {
+		logging.FromContext(ctx, s.logger).Warn().Err(err).Str("source", item.Code).Msg("source test query failed")
+		return fmt.Errorf("%w: test query failed: %v", ErrInvalidSource, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"searchav/internal/auth"
+	"searchav/internal/cache"
+	"searchav/internal/config"
+	"searchav/internal/constants"
+	"searchav/internal/model"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrInvalidPassword is returned when logging in with an unknown password
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrForbidden is returned when the caller's profile does not allow a source or category
+	ErrForbidden = constants.ErrForbidden
+)
+
+const (
+	// verifiedTTL is how long a successful password check is remembered
+	verifiedTTL = 10 * time.Minute
+	// verifiedMaxEntries bounds the remembered password checks
+	verifiedMaxEntries = 1024
+)
+
+// LockedOutError is returned while a client is locked out after repeated
+// failed password attempts
+type LockedOutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedOutError) Error() string {
+	return "too many failed attempts"
+}
+
+// AuthService validates passwords and issues signed session tokens.
+// Password guessing is limited per client IP.
+//
+// Tokens carry a fingerprint of the password entry they were issued for and
+// are resolved against the live entry on every request, so removing, changing
+// or restricting an entry applies to the tokens already issued.
+type AuthService struct {
+	config  *config.Config
+	signer  *auth.Signer
+	limiter *auth.Limiter
+	logger  *zerolog.Logger
+
+	// verified remembers successful password checks, keyed by a fingerprint
+	// of the password, as the entry fingerprint they matched. Clients sending
+	// X-Auth-Password with every request then pay for a hash check only once.
+	verified *cache.Cache[string, string]
+}
+
+// NewAuthService creates a new auth service.
+// Without a configured token secret a random one is generated, so tokens do
+// not survive a restart.
+func NewAuthService(cfg *config.Config, logger *zerolog.Logger) (*AuthService, error) {
+	secret := []byte(cfg.Auth.TokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if cfg.AuthEnabled() {
+			logger.Warn().Msg("auth.token_secret not set, login tokens are invalidated on restart")
+		}
+	}
+
+	var limiter *auth.Limiter
+	if l := cfg.Auth.Lockout; l.Enabled {
+		limiter = auth.NewLimiter(l.MaxAttempts, l.BaseDelay, l.MaxDelay, l.ResetAfter)
+	}
+
+	return &AuthService{
+		config:   cfg,
+		signer:   auth.NewSigner(secret, cfg.Auth.TokenTTL),
+		limiter:  limiter,
+		logger:   logger,
+		verified: cache.New[string, string](verifiedMaxEntries),
+	}, nil
+}
+
+// Login validates a password from the client at ip and issues a token for the
+// password entry it matches
+func (s *AuthService) Login(ip, password string) (*model.LoginResult, error) {
+	result, err := s.validatePassword(ip, password)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		return nil, ErrInvalidPassword
+	}
+
+	token, expires, err := s.signer.Issue(auth.Claims{Entry: result.entry})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResult{
+		Token:     token,
+		ExpiresAt: expires,
+		Profile:   result.Name,
+		Adult:     result.Adult,
+		Admin:     result.Admin,
+	}, nil
+}
+
+// Authenticate resolves the profile of the client at ip from a bearer token
+// or, for clients that do not log in, a raw password.
+// An invalid or expired token is rejected even if a password is given, as is
+// a token whose password entry was removed, changed or has expired.
+func (s *AuthService) Authenticate(ip, token, password string) (config.AuthResult, error) {
+	if !s.config.AuthEnabled() {
+		return openAccess, nil
+	}
+
+	// Tokens are signed and cannot be guessed, so they bypass the lockout
+	if token = strings.TrimSpace(token); token != "" {
+		claims, err := s.signer.Parse(token)
+		if err != nil {
+			return config.AuthResult{}, nil
+		}
+		return s.tokenProfile(claims), nil
+	}
+
+	// Requests without credentials are not password guesses
+	if password == "" {
+		return config.AuthResult{}, nil
+	}
+	result, err := s.validatePassword(ip, password)
+	return result.AuthResult, err
+}
+
+// openAccess is the result of every request while auth is disabled: all
+// content including adult, but never admin
+var openAccess = config.AuthResult{Valid: true, Profile: config.Profile{Adult: true}}
+
+// tokenProfile resolves the profile of a valid token from the live password
+// entry it was issued for
+func (s *AuthService) tokenProfile(claims auth.Claims) config.AuthResult {
+	// Tokens of older versions carry no entry
+	if claims.Entry == "" {
+		return config.AuthResult{}
+	}
+
+	cred, ok := s.findEntry(s.config.Credentials(), claims.Entry)
+	if !ok {
+		return config.AuthResult{}
+	}
+	return config.AuthResult{Valid: true, Profile: cred.Profile}
+}
+
+// passwordResult is a password check with the fingerprint of the matched entry
+type passwordResult struct {
+	config.AuthResult
+	entry string
+}
+
+// LockoutStats returns the password lockout counters
+func (s *AuthService) LockoutStats() auth.LimiterStats {
+	return s.limiter.Stats()
+}
+
+// validatePassword checks a password unless the client is locked out and
+// records the outcome
+func (s *AuthService) validatePassword(ip, password string) (passwordResult, error) {
+	if !s.config.AuthEnabled() {
+		return passwordResult{AuthResult: openAccess}, nil
+	}
+
+	if wait := s.limiter.RetryAfter(ip); wait > 0 {
+		return passwordResult{}, &LockedOutError{RetryAfter: wait}
+	}
+
+	result := s.checkPassword(password)
+	if result.Valid {
+		s.limiter.Reset(ip)
+		return result, nil
+	}
+
+	if lockout := s.limiter.Fail(ip); lockout > 0 {
+		s.logger.Warn().
+			Str("ip", ip).
+			Dur("lockout", lockout).
+			Uint64("lockouts_total", s.limiter.Stats().Lockouts).
+			Msg("client locked out after failed password attempts")
+	}
+	return result, nil
+}
+
+// checkPassword finds the entry a password matches. Successful checks are
+// remembered, so repeated requests with the same password skip the hashing
+// as long as the entry is unchanged.
+func (s *AuthService) checkPassword(password string) passwordResult {
+	creds := s.config.Credentials()
+	key := s.signer.Fingerprint("password", password)
+
+	if entry, ok := s.verified.Get(key); ok {
+		if cred, ok := s.findEntry(creds, entry); ok {
+			return passwordResult{AuthResult: config.AuthResult{Valid: true, Profile: cred.Profile}, entry: entry}
+		}
+		s.verified.Delete(key)
+	}
+
+	for _, cred := range creds {
+		if auth.VerifyPassword(cred.Password, password) {
+			entry := s.entryID(cred)
+			s.verified.Set(key, entry, verifiedTTL)
+			return passwordResult{AuthResult: config.AuthResult{Valid: true, Profile: cred.Profile}, entry: entry}
+		}
+	}
+	return passwordResult{}
+}
+
+// entryID fingerprints a password entry by its stored password and name, so
+// changing either revokes the tokens issued for it
+func (s *AuthService) entryID(cred config.Credential) string {
+	return s.signer.Fingerprint("entry", cred.Name+"\x00"+cred.Password)
+}
+
+// findEntry returns the credential with the given fingerprint
+func (s *AuthService) findEntry(creds []config.Credential, entry string) (config.Credential, bool) {
+	for _, cred := range creds {
+		if s.entryID(cred) == entry {
+			return cred, true
+		}
+	}
+	return config.Credential{}, false
+}
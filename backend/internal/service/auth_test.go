@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"searchav/internal/auth"
+	"searchav/internal/config"
+
+	"github.com/rs/zerolog"
+)
+
+func newTestAuth(t *testing.T, passwords ...config.PasswordItem) (*AuthService, *config.Config) {
+	t.Helper()
+	cfg := &config.Config{Auth: config.AuthConfig{
+		Enabled:     true,
+		Passwords:   passwords,
+		TokenSecret: "test-secret",
+		TokenTTL:    time.Hour,
+	}}
+	logger := zerolog.Nop()
+	svc, err := NewAuthService(cfg, &logger)
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	return svc, cfg
+}
+
+func TestAuthenticateTokenUsesLiveEntry(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(p *config.PasswordItem)
+		wantValid bool
+		wantAdmin bool
+	}{
+		{name: "unchanged", change: func(p *config.PasswordItem) {}, wantValid: true, wantAdmin: true},
+		{name: "downgraded", change: func(p *config.PasswordItem) { p.Admin = false }, wantValid: true},
+		{name: "password changed", change: func(p *config.PasswordItem) { p.Password = "other" }},
+		{name: "renamed", change: func(p *config.PasswordItem) { p.Name = "renamed" }},
+		{name: "expired", change: func(p *config.PasswordItem) { p.Expires = "2020-01-01" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Unnamed entries are resolved like named ones
+			svc, cfg := newTestAuth(t,
+				config.PasswordItem{Password: "secret", Adult: true, Admin: true},
+				config.PasswordItem{Password: "guest"},
+			)
+			login, err := svc.Login("1.2.3.4", "secret")
+			if err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+
+			tt.change(&cfg.Auth.Passwords[0])
+			result, err := svc.Authenticate("1.2.3.4", login.Token, "")
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if result.Valid != tt.wantValid || result.Admin != tt.wantAdmin {
+				t.Errorf("Authenticate = valid %v admin %v, want valid %v admin %v",
+					result.Valid, result.Admin, tt.wantValid, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejectsTokenWithoutEntry(t *testing.T) {
+	svc, _ := newTestAuth(t, config.PasswordItem{Password: "secret", Admin: true})
+
+	// A token signed with the right secret but issued before entries were tracked
+	token, _, err := auth.NewSigner([]byte("test-secret"), time.Hour).Issue(auth.Claims{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	result, err := svc.Authenticate("1.2.3.4", token, "")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if result.Valid {
+		t.Error("token without an entry was accepted")
+	}
+}
+
+func TestAuthenticatePasswordRemembered(t *testing.T) {
+	hash, err := auth.HashPassword("secret", "bcrypt")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	svc, cfg := newTestAuth(t, config.PasswordItem{Name: "family", Password: hash, Adult: true})
+
+	for i := 0; i < 2; i++ {
+		result, err := svc.Authenticate("1.2.3.4", "", "secret")
+		if err != nil || !result.Valid || !result.Adult {
+			t.Fatalf("Authenticate #%d = %+v, %v, want adult access", i, result, err)
+		}
+	}
+	if n := svc.verified.Len(); n != 1 {
+		t.Errorf("remembered %d checks, want 1", n)
+	}
+
+	// Changing the entry invalidates the remembered check
+	cfg.Auth.Passwords[0].Adult = false
+	cfg.Auth.Passwords[0].Password = "changed"
+	result, err := svc.Authenticate("1.2.3.4", "", "secret")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if result.Valid {
+		t.Error("old password accepted after the entry changed")
+	}
+	if result, _ := svc.Authenticate("1.2.3.4", "", "changed"); !result.Valid || result.Adult {
+		t.Errorf("Authenticate with new password = %+v, want non-adult access", result)
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+
+	"searchav/internal/config"
+)
+
+// Authorizer is the single place deciding whether a profile may use a source.
+// Endpoints naming a source authorize it here before touching it.
+type Authorizer struct {
+	config *config.Config
+}
+
+// NewAuthorizer creates a new authorizer
+func NewAuthorizer(cfg *config.Config) *Authorizer {
+	return &Authorizer{
+		config: cfg,
+	}
+}
+
+// Source returns the source with the given code if the profile may use it.
+// Unknown codes yield ErrSourceNotFound, disabled sources and sources outside
+// the profile's rules (including adult sources for non-adult profiles) yield
+// ErrForbidden.
+func (a *Authorizer) Source(profile *config.Profile, code string) (*config.SourceItem, error) {
+	src, ok := a.config.GetSourceByCode(code)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSourceNotFound, code)
+	}
+	if !src.Enabled {
+		return nil, fmt.Errorf("%w: source %s is disabled", ErrForbidden, code)
+	}
+	if !profile.AllowsSource(*src) {
+		return nil, fmt.Errorf("%w: source %s", ErrForbidden, code)
+	}
+	return src, nil
+}
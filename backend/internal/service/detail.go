@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"searchav/internal/config"
@@ -14,34 +15,43 @@ import (
 
 // DetailService handles video detail retrieval
 type DetailService struct {
-	config *config.Config
-	client *source.Client
-	logger *zerolog.Logger
+	config    *config.Config
+	client    source.Provider
+	authz     *Authorizer
+	playHosts *PlayHosts
+	logger    *zerolog.Logger
 }
 
 // NewDetailService creates a new detail service
-func NewDetailService(cfg *config.Config, client *source.Client, logger *zerolog.Logger) *DetailService {
+func NewDetailService(cfg *config.Config, client source.Provider, authz *Authorizer, playHosts *PlayHosts, logger *zerolog.Logger) *DetailService {
 	return &DetailService{
-		config: cfg,
-		client: client,
-		logger: logger,
+		config:    cfg,
+		client:    client,
+		authz:     authz,
+		playHosts: playHosts,
+		logger:    logger,
 	}
 }
 
-// GetDetail retrieves video details from a source
-func (s *DetailService) GetDetail(ctx context.Context, sourceCode string, vodID int) (*model.VideoDetail, error) {
-	src, ok := s.config.GetSourceByCode(sourceCode)
-	if !ok {
-		return nil, fmt.Errorf("source not found: %s", sourceCode)
+// GetDetail retrieves video details from a source.
+// The profile must be authorized for the source and allow the video's category.
+func (s *DetailService) GetDetail(ctx context.Context, sourceCode string, vodID int, profile *config.Profile) (*model.VideoDetail, error) {
+	src, err := s.authz.Source(profile, sourceCode)
+	if err != nil {
+		return nil, err
 	}
 
 	raw, err := s.client.GetDetail(ctx, *src, vodID)
 	if err != nil {
 		return nil, err
 	}
+	if !profile.AllowsCategory(raw.TypeName) {
+		return nil, fmt.Errorf("%w: category %s", ErrForbidden, raw.TypeName)
+	}
 
-	// Parse play URLs into episodes
-	episodes := s.parseEpisodes(raw.VodPlayURL)
+	// Parse play URLs into lines
+	lines := s.parsePlayLines(raw.VodPlayFrom, raw.VodPlayURL)
+	s.playHosts.Record(src.Code, lines)
 
 	return &model.VideoDetail{
 		VodName:     raw.VodName,
@@ -51,46 +61,119 @@ func (s *DetailService) GetDetail(ctx context.Context, sourceCode string, vodID
 		VodArea:     raw.VodArea,
 		VodDirector: raw.VodDirector,
 		VodActor:    raw.VodActor,
-		Episodes:    episodes,
+		Lines:       lines,
+		Episodes:    legacyEpisodes(lines),
 	}, nil
 }
 
-// parseEpisodes parses play URL string into episode URLs
+// parsePlayLines parses play URL strings into play lines
 // Format: name1$url1#name2$url2$$$name1$url1#name2$url2
-// $$$ separates multiple sources, # separates episodes, $ separates name and URL
-func (s *DetailService) parseEpisodes(playURL string) []string {
+// $$$ separates multiple lines, # separates episodes, $ separates name and URL.
+// Line names come from vod_play_from, which uses the same $$$ separator.
+func (s *DetailService) parsePlayLines(playFrom, playURL string) []model.PlayLine {
 	if playURL == "" {
 		return nil
 	}
 
-	// Split by multiple sources
-	sources := strings.Split(playURL, "$$$")
-	if len(sources) == 0 {
-		return nil
-	}
+	names := strings.Split(playFrom, "$$$")
+	groups := strings.Split(playURL, "$$$")
 
-	// Prefer sources containing m3u8
-	selectedSource := sources[0]
-	for _, src := range sources {
-		if strings.Contains(src, ".m3u8") || strings.Contains(src, "m3u8") {
-			selectedSource = src
-			break
+	lines := make([]model.PlayLine, 0, len(groups))
+	for i, group := range groups {
+		episodes := parseEpisodes(group)
+		if len(episodes) == 0 {
+			continue
+		}
+
+		name := ""
+		if i < len(names) {
+			name = strings.TrimSpace(names[i])
 		}
+		if name == "" {
+			name = fmt.Sprintf("Line %d", i+1)
+		}
+
+		lines = append(lines, model.PlayLine{
+			Name:     name,
+			Format:   detectFormat(name, episodes),
+			Episodes: episodes,
+		})
 	}
 
-	// Split episodes
-	epList := strings.Split(selectedSource, "#")
+	return lines
+}
+
+// parseEpisodes parses a single line into episodes, skipping non-HTTP URLs
+func parseEpisodes(group string) []model.Episode {
+	epList := strings.Split(group, "#")
 
-	episodes := make([]string, 0, len(epList))
+	episodes := make([]model.Episode, 0, len(epList))
 	for _, ep := range epList {
-		parts := strings.Split(ep, "$")
+		// Entries are title$url, sometimes followed by a $flag
+		parts := strings.Split(strings.TrimSpace(ep), "$")
+		title, url := "", parts[0]
 		if len(parts) >= 2 {
-			url := parts[1]
-			if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-				episodes = append(episodes, url)
-			}
+			title, url = parts[0], parts[1]
 		}
+
+		url = strings.TrimSpace(url)
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+
+		title = strings.TrimSpace(title)
+		if title == "" {
+			title = strconv.Itoa(len(episodes) + 1)
+		}
+
+		episodes = append(episodes, model.Episode{Title: title, URL: url})
 	}
 
 	return episodes
 }
+
+// detectFormat detects the play format of a line from its name and URLs.
+// Lines that are neither HLS nor direct MP4 are treated as share pages.
+func detectFormat(name string, episodes []model.Episode) string {
+	if strings.Contains(strings.ToLower(name), "m3u8") {
+		return model.PlayFormatM3U8
+	}
+
+	for _, ep := range episodes {
+		path := strings.ToLower(ep.URL)
+		if i := strings.IndexAny(path, "?#"); i >= 0 {
+			path = path[:i]
+		}
+
+		switch {
+		case strings.Contains(path, ".m3u8"):
+			return model.PlayFormatM3U8
+		case strings.HasSuffix(path, ".mp4"):
+			return model.PlayFormatMP4
+		}
+	}
+
+	return model.PlayFormatShare
+}
+
+// legacyEpisodes returns the flat URL list of the preferred line.
+// Lines in m3u8 format are preferred, otherwise the first line is used.
+func legacyEpisodes(lines []model.PlayLine) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	selected := lines[0]
+	for _, line := range lines {
+		if line.Format == model.PlayFormatM3U8 {
+			selected = line
+			break
+		}
+	}
+
+	episodes := make([]string, 0, len(selected.Episodes))
+	for _, ep := range selected.Episodes {
+		episodes = append(episodes, ep.URL)
+	}
+	return episodes
+}
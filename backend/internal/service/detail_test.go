@@ -0,0 +1,133 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"searchav/internal/model"
+)
+
+func TestParsePlayLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		playFrom string
+		playURL  string
+		want     []model.PlayLine
+	}{
+		{
+			name:     "multiple lines",
+			playFrom: "ffm3u8$$$ffyun",
+			playURL:  "第1集$https://a.example.com/1/index.m3u8#第2集$https://a.example.com/2/index.m3u8$$$第1集$https://share.example.com/v/1",
+			want: []model.PlayLine{
+				{Name: "ffm3u8", Format: model.PlayFormatM3U8, Episodes: []model.Episode{
+					{Title: "第1集", URL: "https://a.example.com/1/index.m3u8"},
+					{Title: "第2集", URL: "https://a.example.com/2/index.m3u8"},
+				}},
+				{Name: "ffyun", Format: model.PlayFormatShare, Episodes: []model.Episode{
+					{Title: "第1集", URL: "https://share.example.com/v/1"},
+				}},
+			},
+		},
+		{
+			name:     "three field entries",
+			playFrom: "line",
+			playURL:  "HD$https://a.example.com/movie.mp4$ffm3u8#TC$https://a.example.com/tc.mp4$ffm3u8",
+			want: []model.PlayLine{
+				{Name: "line", Format: model.PlayFormatMP4, Episodes: []model.Episode{
+					{Title: "HD", URL: "https://a.example.com/movie.mp4"},
+					{Title: "TC", URL: "https://a.example.com/tc.mp4"},
+				}},
+			},
+		},
+		{
+			name:     "non-http entries",
+			playFrom: "xigua$$$m3u8",
+			playURL:  "第1集$xg://play/1#第2集$ftp://a.example.com/2$$$第1集$ftp://a.example.com/1#第2集$https://a.example.com/2.m3u8",
+			want: []model.PlayLine{
+				{Name: "m3u8", Format: model.PlayFormatM3U8, Episodes: []model.Episode{
+					{Title: "第2集", URL: "https://a.example.com/2.m3u8"},
+				}},
+			},
+		},
+		{
+			name:     "untitled entries and missing line names",
+			playFrom: "",
+			playURL:  "https://a.example.com/1.m3u8#$https://a.example.com/2.m3u8",
+			want: []model.PlayLine{
+				{Name: "Line 1", Format: model.PlayFormatM3U8, Episodes: []model.Episode{
+					{Title: "1", URL: "https://a.example.com/1.m3u8"},
+					{Title: "2", URL: "https://a.example.com/2.m3u8"},
+				}},
+			},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	svc := &DetailService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := svc.parsePlayLines(tt.playFrom, tt.playURL)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlayLines =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		urls []string
+		want string
+	}{
+		{"m3u8 line name", "ffM3U8", []string{"https://a.example.com/play?id=1"}, model.PlayFormatM3U8},
+		{"m3u8 url", "line", []string{"https://a.example.com/1/index.m3u8?token=x"}, model.PlayFormatM3U8},
+		{"mp4 url", "line", []string{"https://a.example.com/1.MP4"}, model.PlayFormatMP4},
+		{"mp4 in query only", "line", []string{"https://a.example.com/play?file=1.mp4"}, model.PlayFormatShare},
+		{"share link", "ffyun", []string{"https://share.example.com/v/abc"}, model.PlayFormatShare},
+		{"later episode decides", "line", []string{"https://share.example.com/v/1", "https://a.example.com/2.m3u8"}, model.PlayFormatM3U8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			episodes := make([]model.Episode, 0, len(tt.urls))
+			for _, u := range tt.urls {
+				episodes = append(episodes, model.Episode{URL: u})
+			}
+			if got := detectFormat(tt.line, episodes); got != tt.want {
+				t.Errorf("detectFormat = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyEpisodes(t *testing.T) {
+	share := model.PlayLine{Name: "share", Format: model.PlayFormatShare, Episodes: []model.Episode{{URL: "https://share.example.com/1"}}}
+	mp4 := model.PlayLine{Name: "mp4", Format: model.PlayFormatMP4, Episodes: []model.Episode{{URL: "https://a.example.com/1.mp4"}}}
+	hls1 := model.PlayLine{Name: "hls1", Format: model.PlayFormatM3U8, Episodes: []model.Episode{{URL: "https://a.example.com/1.m3u8"}, {URL: "https://a.example.com/2.m3u8"}}}
+	hls2 := model.PlayLine{Name: "hls2", Format: model.PlayFormatM3U8, Episodes: []model.Episode{{URL: "https://b.example.com/1.m3u8"}}}
+
+	tests := []struct {
+		name  string
+		lines []model.PlayLine
+		want  []string
+	}{
+		{"first m3u8 line", []model.PlayLine{share, hls1, hls2}, []string{"https://a.example.com/1.m3u8", "https://a.example.com/2.m3u8"}},
+		{"first line without m3u8", []model.PlayLine{share, mp4}, []string{"https://share.example.com/1"}},
+		{"no lines", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacyEpisodes(tt.lines); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("legacyEpisodes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"searchav/internal/model"
+)
+
+// SearchFilter narrows search results, empty fields match everything
+type SearchFilter struct {
+	// Type matches the coarse kind (movie, series, anime, variety,
+	// documentary) or the exact category name of a result
+	Type string
+	// Year matches the release year, such as "2023"
+	Year string
+	// Area matches one of the areas of a result, such as "大陆"
+	Area string
+}
+
+// Facet attributes
+const (
+	facetType = iota
+	facetYear
+	facetArea
+	facetCount
+)
+
+// match reports whether an item passes the filter, ignoring the filter on
+// the attribute skip so that attribute can be counted
+func (f SearchFilter) match(item *model.VideoItem, skip int) bool {
+	if skip != facetType && f.Type != "" && f.Type != item.Kind && f.Type != item.TypeName {
+		return false
+	}
+	if skip != facetYear && f.Year != "" && f.Year != item.VodYear {
+		return false
+	}
+	if skip != facetArea && f.Area != "" && !containsArea(item.VodArea, f.Area) {
+		return false
+	}
+	return true
+}
+
+// filterResult returns the results of a search matching the filter with
+// their facet counts. The shared search result is left unchanged.
+func filterResult(result *model.SearchResult, f SearchFilter) *model.SearchResult {
+	list := make([]model.VideoItem, 0, len(result.List))
+	var counts [facetCount]map[string]int
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+
+	for i := range result.List {
+		item := &result.List[i]
+		if f.match(item, -1) {
+			list = append(list, *item)
+		}
+
+		if item.Kind != "" && f.match(item, facetType) {
+			counts[facetType][item.Kind]++
+		}
+		if item.VodYear != "" && f.match(item, facetYear) {
+			counts[facetYear][item.VodYear]++
+		}
+		if f.match(item, facetArea) {
+			for _, area := range splitAreas(item.VodArea) {
+				counts[facetArea][area]++
+			}
+		}
+	}
+
+	return &model.SearchResult{
+		List:    list,
+		Sources: result.Sources,
+		Facets: &model.SearchFacets{
+			Type: sortCounts(counts[facetType], false),
+			Year: sortCounts(counts[facetYear], true),
+			Area: sortCounts(counts[facetArea], false),
+		},
+	}
+}
+
+// sortCounts lists facet counts, most frequent first, or by descending value
+// when byValue is set
+func sortCounts(counts map[string]int, byValue bool) []model.FacetCount {
+	list := make([]model.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		list = append(list, model.FacetCount{Value: value, Count: count})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if byValue {
+			return list[i].Value > list[j].Value
+		}
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Value < list[j].Value
+	})
+	return list
+}
+
+// splitAreas splits an area listing such as "大陆,香港" into its areas
+func splitAreas(area string) []string {
+	return strings.FieldsFunc(area, func(r rune) bool {
+		switch r {
+		case ',', '，', '/', '、', ' ', '|':
+			return true
+		}
+		return false
+	})
+}
+
+// containsArea reports whether an area listing contains an area
+func containsArea(listing, area string) bool {
+	for _, a := range splitAreas(listing) {
+		if a == area {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"searchav/internal/config"
+	"searchav/internal/constants"
+	"searchav/internal/hls"
+	"searchav/internal/logging"
+	"searchav/internal/source"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxVariantDepth limits how many master playlists are followed
+	maxVariantDepth = 3
+)
+
+// ErrInvalidPlaylistURL is returned for playlist URLs that are not absolute http(s) URLs
+var ErrInvalidPlaylistURL = errors.New("invalid playlist url")
+
+// ErrPlaylistHostRefused is returned for playlists on hosts the source never pointed to
+var ErrPlaylistHostRefused = fmt.Errorf("%w: playlist host not used by source", ErrForbidden)
+
+// HLSService fetches HLS playlists and removes ad segments
+type HLSService struct {
+	config    *config.Config
+	fetcher   source.Fetcher
+	authz     *Authorizer
+	playHosts *PlayHosts
+	logger    *zerolog.Logger
+}
+
+// NewHLSService creates a new HLS service
+func NewHLSService(cfg *config.Config, fetcher source.Fetcher, authz *Authorizer, playHosts *PlayHosts, logger *zerolog.Logger) *HLSService {
+	return &HLSService{
+		config:    cfg,
+		fetcher:   fetcher,
+		authz:     authz,
+		playHosts: playHosts,
+		logger:    logger,
+	}
+}
+
+// CleanPlaylist fetches a playlist of a source, resolves master playlists to
+// the best variant, rewrites relative URIs to absolute and removes ad
+// segments. The profile must be authorized for the source, and the playlist
+// must be on the source's API host or on a host of the play URLs it returned.
+func (s *HLSService) CleanPlaylist(ctx context.Context, sourceCode, rawURL string, profile *config.Profile) (string, error) {
+	src, err := s.authz.Source(profile, sourceCode)
+	if err != nil {
+		return "", err
+	}
+
+	logger := logging.FromContext(ctx, s.logger)
+	playlistURL, err := url.Parse(rawURL)
+	if err != nil || (playlistURL.Scheme != "http" && playlistURL.Scheme != "https") || playlistURL.Host == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPlaylistURL, rawURL)
+	}
+	if !s.sourceHost(src, playlistURL.Hostname()) {
+		return "", fmt.Errorf("%w: %s", ErrPlaylistHostRefused, playlistURL.Host)
+	}
+
+	content, err := s.fetch(ctx, playlistURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Follow master playlists to the highest bandwidth variant
+	for depth := 0; hls.IsMaster(content); depth++ {
+		if depth >= maxVariantDepth {
+			return "", fmt.Errorf("%w: too many nested master playlists: %s", constants.ErrUpstreamBadPayload, rawURL)
+		}
+
+		variant, ok := hls.BestVariant(hls.ParseMaster(content))
+		if !ok {
+			return "", fmt.Errorf("%w: master playlist has no variants: %s", constants.ErrUpstreamBadPayload, playlistURL)
+		}
+
+		playlistURL, err = url.Parse(hls.ResolveURI(playlistURL, variant.URI))
+		if err != nil {
+			return "", err
+		}
+
+		logger.Debug().Str("variant", playlistURL.String()).Int("bandwidth", variant.Bandwidth).Msg("following master playlist")
+
+		if content, err = s.fetch(ctx, playlistURL); err != nil {
+			return "", err
+		}
+	}
+
+	playlist := hls.ParseMedia(content)
+	playlist.ResolveURIs(playlistURL)
+
+	opts := hls.DefaultFilterOptions
+	if d := s.config.HLS.MaxAdRunDuration; d > 0 {
+		opts.MaxAdRunDuration = d.Seconds()
+	}
+	result := hls.FilterAds(playlist, opts)
+
+	logger.Info().
+		Str("url", playlistURL.String()).
+		Int("segments", len(playlist.Segments)).
+		Int("ads_removed", result.Removed).
+		Float64("ads_duration", result.RemovedDuration).
+		Msg("playlist cleaned")
+
+	return playlist.String(), nil
+}
+
+// fetch fetches a playlist and checks that it is an m3u8 document
+func (s *HLSService) fetch(ctx context.Context, playlistURL *url.URL) (string, error) {
+	body, err := s.fetcher.Fetch(ctx, playlistURL.String())
+	if err != nil {
+		return "", err
+	}
+
+	content := string(body)
+	if !hls.IsPlaylist(content) {
+		return "", fmt.Errorf("%w: not an m3u8 playlist: %s", constants.ErrUpstreamBadPayload, playlistURL)
+	}
+	return content, nil
+}
+
+// sourceHost reports whether a host is the API host of a source, one of its
+// subdomains, or a host the source returned play URLs on
+func (s *HLSService) sourceHost(src *config.SourceItem, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if api, err := url.Parse(src.URL); err == nil {
+		apiHost := strings.ToLower(strings.TrimSuffix(api.Hostname(), "."))
+		if apiHost != "" && (host == apiHost || strings.HasSuffix(host, "."+apiHost)) {
+			return true
+		}
+	}
+	return s.playHosts.Allowed(src.Code, host)
+}
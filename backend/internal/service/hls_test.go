@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/constants"
+
+	"github.com/rs/zerolog"
+)
+
+// mapFetcher serves playlists by URL
+type mapFetcher map[string]string
+
+func (f mapFetcher) Fetch(_ context.Context, rawURL string) ([]byte, error) {
+	body, ok := f[rawURL]
+	if !ok {
+		return nil, constants.ErrUpstreamError
+	}
+	return []byte(body), nil
+}
+
+// adPlaylist has a 15 second discontinuity run named unlike the content
+const adPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXTINF:10,
+000001.ts
+#EXTINF:10,
+000002.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:15,
+ad-a1b2.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:10,
+000003.ts
+#EXT-X-ENDLIST
+`
+
+func newTestHLS(t *testing.T, hlsCfg config.HLSConfig, fetcher mapFetcher) *HLSService {
+	t.Helper()
+	cfg := &config.Config{
+		HLS: hlsCfg,
+		Sources: []config.SourceItem{
+			{Code: "pub", Name: "Public", URL: "https://api.example.com/provide/vod", Enabled: true},
+		},
+	}
+	logger := zerolog.Nop()
+	return NewHLSService(cfg, fetcher, NewAuthorizer(cfg), NewPlayHosts(), &logger)
+}
+
+func TestCleanPlaylistErrors(t *testing.T) {
+	master := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000\nhttps://api.example.com/master.m3u8\n"
+	fetcher := mapFetcher{
+		"https://api.example.com/page.html":   "<html></html>",
+		"https://api.example.com/master.m3u8": master,
+		"https://api.example.com/empty.m3u8":  "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000\n",
+	}
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"not a playlist", "https://api.example.com/page.html", constants.ErrUpstreamBadPayload},
+		{"nested masters", "https://api.example.com/master.m3u8", constants.ErrUpstreamBadPayload},
+		{"no variants", "https://api.example.com/empty.m3u8", constants.ErrUpstreamBadPayload},
+		{"foreign host", "https://cdn.example.net/index.m3u8", constants.ErrForbidden},
+		{"invalid url", "ftp://api.example.com/index.m3u8", ErrInvalidPlaylistURL},
+	}
+
+	svc := newTestHLS(t, config.HLSConfig{}, fetcher)
+	profile := &config.Profile{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CleanPlaylist(context.Background(), "pub", tt.url, profile)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("CleanPlaylist error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanPlaylistMaxAdRunDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		hls     config.HLSConfig
+		removed bool
+	}{
+		{"unset uses the default", config.HLSConfig{}, true},
+		{"longer than the run", config.HLSConfig{MaxAdRunDuration: 20 * time.Second}, true},
+		{"shorter than the run", config.HLSConfig{MaxAdRunDuration: 10 * time.Second}, false},
+	}
+
+	const playlistURL = "https://media.api.example.com/index.m3u8"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestHLS(t, tt.hls, mapFetcher{playlistURL: adPlaylist})
+			out, err := svc.CleanPlaylist(context.Background(), "pub", playlistURL, &config.Profile{})
+			if err != nil {
+				t.Fatalf("CleanPlaylist: %v", err)
+			}
+			if removed := !strings.Contains(out, "ad-a1b2.ts"); removed != tt.removed {
+				t.Errorf("ad removed = %v, want %v:\n%s", removed, tt.removed, out)
+			}
+		})
+	}
+}
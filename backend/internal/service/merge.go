@@ -0,0 +1,163 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"searchav/internal/model"
+	"searchav/internal/ranking"
+	"searchav/internal/source"
+	"searchav/internal/title"
+)
+
+// Coarse video kinds shared by the differently named categories of sources
+const (
+	kindMovie       = "movie"
+	kindSeries      = "series"
+	kindAnime       = "anime"
+	kindVariety     = "variety"
+	kindDocumentary = "documentary"
+)
+
+// yearPattern finds a release year in vod_year values like "2013" or "2013-04"
+var yearPattern = regexp.MustCompile(`(?:19|20)[0-9]{2}`)
+
+// mergeGroup is a merged result with the attributes its entries must agree on
+type mergeGroup struct {
+	item *model.VideoItem
+	year string
+	kind string
+	// variant is set while the displayed title is a labelled variant
+	variant bool
+}
+
+// accepts reports whether an entry belongs to the group. Year and kind must
+// match when both sides know them, so remakes sharing a title stay apart.
+func (g *mergeGroup) accepts(year, kind string) bool {
+	return compatible(g.year, year) && compatible(g.kind, kind)
+}
+
+// candidate describes the group for ranking
+func (g *mergeGroup) candidate() ranking.Candidate {
+	year, _ := strconv.Atoi(g.year)
+
+	codes := make([]string, 0, len(g.item.Sources))
+	seen := make(map[string]bool, len(g.item.Sources))
+	for _, src := range g.item.Sources {
+		if !seen[src.SourceCode] {
+			seen[src.SourceCode] = true
+			codes = append(codes, src.SourceCode)
+		}
+	}
+
+	return ranking.Candidate{Title: g.item.VodName, Year: year, Sources: codes}
+}
+
+// mergeResults merges and deduplicates search results, in the order the
+// entries were first seen.
+// Entries are merged on their normalized title, see title.Normalize, when
+// their year and kind agree. Dubbed, quality and season variants of a title
+// are merged into one result, each source labelled with its variant.
+func (s *SearchService) mergeResults(raw []source.RawVideo) []*mergeGroup {
+	groups := make(map[string][]*mergeGroup)
+	var order []*mergeGroup
+
+	for _, v := range raw {
+		name := strings.TrimSpace(v.VodName)
+		if name == "" {
+			continue
+		}
+
+		t := title.Normalize(name)
+		variant := t.Variant()
+		year := yearPattern.FindString(v.VodYear)
+		kind := typeKind(v.TypeName)
+		info := model.SourceInfo{
+			SourceCode: v.SourceCode,
+			SourceName: v.SourceName,
+			VodID:      v.VodID,
+			VodName:    name,
+			Variant:    variant,
+		}
+
+		var group *mergeGroup
+		for _, g := range groups[t.Key] {
+			if g.accepts(year, kind) {
+				group = g
+				break
+			}
+		}
+
+		if group == nil {
+			// New entry
+			group = &mergeGroup{
+				item: &model.VideoItem{
+					VodName:    name,
+					VodPic:     v.VodPic,
+					VodRemarks: v.VodRemarks,
+					TypeName:   v.TypeName,
+					VodArea:    v.VodArea,
+					Sources:    []model.SourceInfo{info},
+				},
+				year:    year,
+				kind:    kind,
+				variant: variant != "",
+			}
+			groups[t.Key] = append(groups[t.Key], group)
+			order = append(order, group)
+			continue
+		}
+
+		// Exists, append source and fill in what the group lacks
+		item := group.item
+		item.Sources = append(item.Sources, info)
+		if group.year == "" {
+			group.year = year
+		}
+		if group.kind == "" {
+			group.kind = kind
+		}
+		if item.VodPic == "" {
+			item.VodPic = v.VodPic
+		}
+		if item.VodArea == "" {
+			item.VodArea = v.VodArea
+		}
+		// Prefer the plain title over a variant for display
+		if group.variant && variant == "" {
+			item.VodName = name
+			item.VodRemarks = v.VodRemarks
+			group.variant = false
+		}
+	}
+
+	return order
+}
+
+// typeKind maps a source category name to a coarse kind, empty when unknown
+func typeKind(typeName string) string {
+	name := strings.TrimSpace(typeName)
+	switch {
+	case name == "":
+		return ""
+	case strings.Contains(name, "动漫"), strings.Contains(name, "动画"), strings.Contains(name, "番"):
+		return kindAnime
+	case strings.Contains(name, "综艺"):
+		return kindVariety
+	case strings.Contains(name, "纪录"):
+		return kindDocumentary
+	case strings.HasSuffix(name, "片"), strings.Contains(name, "电影"):
+		return kindMovie
+	case strings.Contains(name, "剧"):
+		return kindSeries
+	default:
+		return ""
+	}
+}
+
+// compatible reports whether two attribute values may describe the same
+// work, unknown values matching anything
+func compatible(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
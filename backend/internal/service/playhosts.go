@@ -0,0 +1,51 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"searchav/internal/cache"
+	"searchav/internal/model"
+)
+
+const (
+	// playHostsTTL is how long the host of a returned play URL stays playable
+	playHostsTTL = 24 * time.Hour
+	// maxPlayHosts bounds the remembered source and host pairs
+	maxPlayHosts = 10000
+)
+
+// PlayHosts remembers the hosts of the play URLs sources returned, so the
+// HLS proxy only fetches playlists a source actually pointed to
+type PlayHosts struct {
+	hosts *cache.Cache[string, struct{}]
+}
+
+// NewPlayHosts creates an empty play host registry
+func NewPlayHosts() *PlayHosts {
+	return &PlayHosts{
+		hosts: cache.New[string, struct{}](maxPlayHosts),
+	}
+}
+
+// Record remembers the hosts of the episodes of a source's play lines
+func (p *PlayHosts) Record(sourceCode string, lines []model.PlayLine) {
+	for _, line := range lines {
+		for _, ep := range line.Episodes {
+			if u, err := url.Parse(ep.URL); err == nil && u.Host != "" {
+				p.hosts.Set(playHostKey(sourceCode, u.Hostname()), struct{}{}, playHostsTTL)
+			}
+		}
+	}
+}
+
+// Allowed reports whether a source returned a play URL on the host
+func (p *PlayHosts) Allowed(sourceCode, host string) bool {
+	_, ok := p.hosts.Get(playHostKey(sourceCode, host))
+	return ok
+}
+
+func playHostKey(sourceCode, host string) string {
+	return sourceCode + "\x00" + strings.ToLower(strings.TrimSuffix(host, "."))
+}
@@ -2,196 +2,573 @@ package service
 
 import (
 	"context"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"searchav/internal/config"
+	"searchav/internal/logging"
+	"searchav/internal/metrics"
 	"searchav/internal/model"
+	"searchav/internal/ranking"
 	"searchav/internal/source"
+	"searchav/internal/title"
+	"searchav/internal/tracing"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+var tracer = otel.Tracer("searchav/internal/service")
+
+const (
+	// maxKeywords bounds the keywords each source is searched for per query, as
+	// every keyword is a request to the source
+	maxKeywords = 4
+	// streamBatchWindow is how long a streamed search collects source results
+	// before ranking them into one results event
+	streamBatchWindow = 100 * time.Millisecond
 )
 
 // SearchService handles video search aggregation
 type SearchService struct {
-	config *config.Config
-	client *source.Client
-	logger *zerolog.Logger
+	config  *config.Config
+	client  source.Provider
+	metrics *metrics.Metrics
+	logger  *zerolog.Logger
+
+	// pinyin maps pinyin queries to titles seen in results, nil when disabled
+	pinyin *title.PinyinIndex
+	// scorer ranks merged results, see search.ranking
+	scorer ranking.Scorer
+
+	// group collapses concurrent identical searches into one fan-out
+	group     singleflight.Group
+	searches  atomic.Uint64
+	coalesced atomic.Uint64
+
+	// shared holds the in-flight shared fan-outs by search key
+	sharedMu sync.Mutex
+	shared   map[string]*sharedSearch
+}
+
+// sharedSearch is the span of a shared fan-out and the callers linked to it
+type sharedSearch struct {
+	span   trace.Span
+	linked map[trace.SpanID]bool
+}
+
+// SearchStats are request coalescing counters
+type SearchStats struct {
+	Searches  uint64 `json:"searches"`
+	Coalesced uint64 `json:"coalesced"`
 }
 
 // NewSearchService creates a new search service
-func NewSearchService(cfg *config.Config, client *source.Client, logger *zerolog.Logger) *SearchService {
-	return &SearchService{
-		config: cfg,
-		client: client,
-		logger: logger,
+func NewSearchService(cfg *config.Config, client source.Provider, m *metrics.Metrics, logger *zerolog.Logger) (*SearchService, error) {
+	scorer, err := ranking.New(cfg.Search.Ranking)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SearchService{
+		config:  cfg,
+		client:  client,
+		metrics: m,
+		logger:  logger,
+		scorer:  scorer,
+		shared:  make(map[string]*sharedSearch),
+	}
+	if cfg.Search.Pinyin.Enabled {
+		s.pinyin = title.NewPinyinIndex(cfg.Search.Pinyin.MaxTitles)
 	}
+	return s, nil
+}
+
+// SearchOptions controls which sources an aggregated search queries
+type SearchOptions struct {
+	// IncludeAdult includes adult sources
+	IncludeAdult bool
+	// Sources restricts the search to these source codes, empty means all
+	Sources []string
+	// Profile holds the caller's access rules, nil means unrestricted
+	Profile *config.Profile
+	// Filter narrows the results, it does not change the sources searched
+	Filter SearchFilter
+}
+
+// searchKey returns a stable key identifying a search over the selected
+// sources as seen by a profile
+func searchKey(keyword string, sources []config.SourceItem, profile *config.Profile) string {
+	codes := make([]string, 0, len(sources))
+	for _, src := range sources {
+		codes = append(codes, src.Code)
+	}
+
+	var categories []string
+	if profile != nil {
+		categories = append(categories, profile.Categories...)
+		sort.Strings(categories)
+	}
+
+	return strings.Join([]string{
+		strings.TrimSpace(keyword),
+		strings.Join(codes, ","),
+		strings.Join(categories, ","),
+	}, "\x00")
 }
 
 // sourceResult holds result from a single source
 type sourceResult struct {
-	source config.SourceItem
-	list   []source.RawVideo
-	err    error
+	source  config.SourceItem
+	list    []source.RawVideo
+	err     error
+	latency time.Duration
 }
 
-// Search performs aggregated search across all sources
-func (s *SearchService) Search(ctx context.Context, keyword string, includeAdult bool) ([]model.VideoItem, error) {
-	allSources := s.config.GetEnabledSources()
-
-	// Filter sources based on adult flag
-	var sources []config.SourceItem
-	for _, src := range allSources {
-		if includeAdult || !src.Adult {
-			sources = append(sources, src)
+// Search performs aggregated search across all sources, returning the
+// results matching the filter and their facet counts.
+// Concurrent identical searches share a single fan-out and its result,
+// which callers must treat as read-only.
+func (s *SearchService) Search(ctx context.Context, keyword string, opts SearchOptions) (*model.SearchResult, error) {
+	sources := s.selectSources(opts)
+	key := searchKey(keyword, sources, opts.Profile)
+	s.searches.Add(1)
+
+	// The shared fan-out belongs to no single caller: it must outlive their
+	// cancellation and must not carry their request ID, logger or span
+	leader := false
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+		shared, span := s.startShared(ctx, key)
+		defer s.endShared(key, span)
+		return s.search(shared, keyword, sources, opts)
+	})
+	s.joinShared(ctx, key)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if !leader {
+			total := s.coalesced.Add(1)
+			logging.FromContext(ctx, s.logger).Info().
+				Str("keyword", keyword).
+				Bool("coalesced", true).
+				Uint64("coalesced_total", total).
+				Msg("search joined in-flight request")
 		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return filterResult(res.Val.(*model.SearchResult), opts.Filter), nil
 	}
+}
 
-	s.logger.Info().Int("sources", len(sources)).Str("keyword", keyword).Bool("adult", includeAdult).Msg("starting aggregated search")
+// startShared starts the context of a shared fan-out, a new trace linked to
+// the caller that started it. Callers joining later are linked by joinShared.
+func (s *SearchService) startShared(caller context.Context, key string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(context.Background(), "search.shared",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(caller)),
+	)
+
+	logger := s.logger.With().Bool("shared", true).Logger()
+	if sc := span.SpanContext(); sc.IsSampled() {
+		logger = logger.With().Str("trace_id", sc.TraceID().String()).Logger()
+	}
 
-	if len(sources) == 0 {
-		s.logger.Warn().Msg("no enabled sources")
-		return nil, nil
+	s.sharedMu.Lock()
+	s.shared[key] = &sharedSearch{
+		span:   span,
+		linked: map[trace.SpanID]bool{trace.SpanContextFromContext(caller).SpanID(): true},
 	}
+	s.sharedMu.Unlock()
+	return logger.WithContext(ctx), span
+}
 
-	results := make(chan sourceResult, len(sources))
-	var wg sync.WaitGroup
+// endShared ends the span of a shared fan-out
+func (s *SearchService) endShared(key string, span trace.Span) {
+	s.sharedMu.Lock()
+	delete(s.shared, key)
+	s.sharedMu.Unlock()
+	span.End()
+}
 
-	// Concurrent requests to all sources
-	for _, src := range sources {
-		wg.Add(1)
-		go func(src config.SourceItem) {
-			defer wg.Done()
-			s.logger.Info().Str("source", src.Code).Str("url", src.URL).Msg("requesting source")
+// joinShared links the caller's span and the span of the shared fan-out
+// serving it, both ways
+func (s *SearchService) joinShared(caller context.Context, key string) {
+	callerSpan := trace.SpanFromContext(caller)
+	id := callerSpan.SpanContext().SpanID()
+	if !id.IsValid() {
+		return
+	}
 
-			list, err := s.client.SearchWithTimeout(ctx, src, keyword, s.config.Source.Timeout)
-			results <- sourceResult{source: src, list: list, err: err}
-		}(src)
+	s.sharedMu.Lock()
+	defer s.sharedMu.Unlock()
+	shared, ok := s.shared[key]
+	if !ok {
+		return
 	}
+	if !shared.linked[id] {
+		shared.linked[id] = true
+		shared.span.AddLink(trace.LinkFromContext(caller))
+	}
+	callerSpan.AddLink(trace.Link{SpanContext: shared.span.SpanContext()})
+}
 
-	// Wait for all requests and close channel
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+// Stats returns the request coalescing counters
+func (s *SearchService) Stats() SearchStats {
+	return SearchStats{
+		Searches:  s.searches.Load(),
+		Coalesced: s.coalesced.Load(),
+	}
+}
+
+// PinyinTitles returns the number of titles in the pinyin index
+func (s *SearchService) PinyinTitles() int {
+	if s.pinyin == nil {
+		return 0
+	}
+	return s.pinyin.Len()
+}
+
+// keywords returns what sources are searched for: the keyword in both
+// Chinese scripts, and for pinyin queries the seen titles they may stand for,
+// at most maxKeywords in all. Titles are only taken from the searched sources
+// and the categories the profile may see.
+func (s *SearchService) keywords(ctx context.Context, keyword string, sources []config.SourceItem, profile *config.Profile) []string {
+	keyword = strings.TrimSpace(keyword)
+	keywords := title.ScriptVariants(keyword)
+
+	if s.pinyin != nil && title.IsPinyinQuery(keyword) {
+		searched := make(map[string]bool, len(sources))
+		for _, src := range sources {
+			searched[src.Code] = true
+		}
+		allow := func(o title.Origin) bool {
+			return searched[o.Source] && (profile == nil || profile.AllowsCategory(o.Category))
+		}
+
+		candidates := s.pinyin.Lookup(keyword, s.config.Search.Pinyin.MaxCandidates, allow)
+		if len(candidates) > 0 {
+			logging.FromContext(ctx, s.logger).Info().
+				Str("keyword", keyword).
+				Strs("candidates", candidates).
+				Msg("pinyin query expanded")
+		}
+		for _, c := range candidates {
+			if !slices.Contains(keywords, c) {
+				keywords = append(keywords, c)
+			}
+		}
+	}
+	return keywords[:min(len(keywords), maxKeywords)]
+}
+
+// remember adds result titles to the pinyin index with the sources listing them
+func (s *SearchService) remember(items []model.VideoItem) {
+	if s.pinyin == nil {
+		return
+	}
+	for _, item := range items {
+		origins := make([]title.Origin, 0, len(item.Sources))
+		for _, src := range item.Sources {
+			origins = append(origins, title.Origin{Source: src.SourceCode, Category: item.TypeName})
+		}
+		s.pinyin.Add(item.VodName, origins...)
+	}
+}
+
+// search runs the aggregated search fan-out over the selected sources
+func (s *SearchService) search(ctx context.Context, keyword string, sources []config.SourceItem, opts SearchOptions) (*model.SearchResult, error) {
+	ctx, span := tracer.Start(ctx, "search.fanout")
+	span.SetAttributes(tracing.AttrKeyword.String(keyword), tracing.AttrSources.Int(len(sources)))
+	defer span.End()
+
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Info().Int("sources", len(sources)).Str("keyword", keyword).Bool("adult", opts.IncludeAdult).Msg("starting aggregated search")
 
-	// Collect results
+	if len(sources) == 0 {
+		logger.Warn().Msg("no enabled sources")
+		return &model.SearchResult{}, nil
+	}
+
+	// Collect results, keeping statuses in configured source order
+	start := time.Now()
+	keywords := s.keywords(ctx, keyword, sources, opts.Profile)
+	statuses := make(map[string]model.SourceStatus, len(sources))
 	var allResults []source.RawVideo
-	for r := range results {
+	for r := range s.fanOut(ctx, sources, keywords, opts.Profile) {
+		statuses[r.source.Code] = r.status()
 		if r.err != nil {
-			s.logger.Warn().Err(r.err).Str("source", r.source.Code).Msg("source request failed")
+			logger.Warn().Err(r.err).Str("source", r.source.Code).Msg("source request failed")
 			continue
 		}
-		s.logger.Info().Str("source", r.source.Code).Int("count", len(r.list)).Msg("source returned results")
+		logger.Info().Str("source", r.source.Code).Int("count", len(r.list)).Msg("source returned results")
 		allResults = append(allResults, r.list...)
 	}
 
-	s.logger.Info().Int("total", len(allResults)).Msg("collection complete, starting merge")
+	report := make([]model.SourceStatus, 0, len(sources))
+	for _, src := range sources {
+		report = append(report, statuses[src.Code])
+	}
+
+	logger.Info().Int("total", len(allResults)).Msg("collection complete, starting merge")
 
-	// Merge and deduplicate
-	merged := s.mergeResults(allResults)
-	s.logger.Info().Int("merged", len(merged)).Msg("merge complete")
+	// Merge, deduplicate and sort by relevance
+	merged := s.rankResults(allResults, rankQuery(keyword, keywords, len(sources)))
+	logger.Info().Int("merged", len(merged)).Msg("merge complete")
+	s.remember(merged)
 
-	// Sort by relevance
-	s.sortByRelevance(merged, keyword)
-	s.logger.Info().Msg("sort complete")
+	s.metrics.Search(time.Since(start), len(merged))
+	span.SetAttributes(tracing.AttrResultCount.Int(len(merged)))
 
-	return merged, nil
+	return &model.SearchResult{List: merged, Sources: report}, nil
 }
 
-// mergeResults merges and deduplicates search results
-func (s *SearchService) mergeResults(raw []source.RawVideo) []model.VideoItem {
-	merged := make(map[string]*model.VideoItem)
+// SearchStream performs aggregated search and emits events as each source responds.
+// A source event is emitted per source. Results arriving within
+// streamBatchWindow of each other are ranked together into one results event,
+// carrying the merge of everything received so far that matches the filter.
+// A final done event carries the facet counts. The search stops early if emit
+// returns an error.
+func (s *SearchService) SearchStream(ctx context.Context, keyword string, opts SearchOptions, emit func(model.SearchEvent) error) error {
+	logger := logging.FromContext(ctx, s.logger)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	for _, v := range raw {
-		key := strings.TrimSpace(v.VodName)
-		if key == "" {
-			continue
+	start := time.Now()
+	sources := s.selectSources(opts)
+
+	ctx, span := tracer.Start(ctx, "search.stream")
+	span.SetAttributes(tracing.AttrKeyword.String(keyword), tracing.AttrSources.Int(len(sources)))
+	defer span.End()
+
+	logger.Info().Int("sources", len(sources)).Str("keyword", keyword).Bool("adult", opts.IncludeAdult).Msg("starting streamed search")
+
+	summary := model.SearchSummary{Sources: len(sources)}
+	var allResults []source.RawVideo
+	var merged []model.VideoItem
+	filtered := filterResult(&model.SearchResult{}, opts.Filter)
+
+	if len(sources) > 0 {
+		keywords := s.keywords(ctx, keyword, sources, opts.Profile)
+		query := rankQuery(keyword, keywords, len(sources))
+
+		// Rank everything received so far and emit it
+		pending := false
+		flush := func() error {
+			pending = false
+			merged = s.rankResults(allResults, query)
+			filtered = filterResult(&model.SearchResult{List: merged}, opts.Filter)
+			summary.Total = len(filtered.List)
+			return emit(model.SearchEvent{Type: model.SearchEventResults, Data: filtered.List})
 		}
 
-		if item, ok := merged[key]; ok {
-			// Exists, append source
-			item.Sources = append(item.Sources, model.SourceInfo{
-				SourceCode: v.SourceCode,
-				SourceName: v.SourceName,
-				VodID:      v.VodID,
-			})
-		} else {
-			// New entry
-			merged[key] = &model.VideoItem{
-				VodName:    v.VodName,
-				VodPic:     v.VodPic,
-				VodRemarks: v.VodRemarks,
-				TypeName:   v.TypeName,
-				Sources: []model.SourceInfo{{
-					SourceCode: v.SourceCode,
-					SourceName: v.SourceName,
-					VodID:      v.VodID,
-				}},
+		batch := time.NewTimer(streamBatchWindow)
+		batch.Stop()
+		defer batch.Stop()
+
+		results := s.fanOut(ctx, sources, keywords, opts.Profile)
+		for results != nil {
+			select {
+			case r, ok := <-results:
+				if !ok {
+					results = nil
+					continue
+				}
+				if r.err != nil {
+					logger.Warn().Err(r.err).Str("source", r.source.Code).Msg("source request failed")
+					summary.Failed++
+				} else {
+					summary.Succeeded++
+				}
+
+				if err := emit(model.SearchEvent{Type: model.SearchEventSource, Data: r.status()}); err != nil {
+					return err
+				}
+
+				if r.err != nil || len(r.list) == 0 {
+					continue
+				}
+				allResults = append(allResults, r.list...)
+				if !pending {
+					pending = true
+					batch.Reset(streamBatchWindow)
+				}
+			case <-batch.C:
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if pending {
+			if err := flush(); err != nil {
+				return err
 			}
 		}
 	}
 
-	// Convert map to slice
-	result := make([]model.VideoItem, 0, len(merged))
-	for _, v := range merged {
-		result = append(result, *v)
+	summary.DurationMs = time.Since(start).Milliseconds()
+	summary.Facets = filtered.Facets
+	s.remember(merged)
+	if len(sources) > 0 {
+		// Counted like Search, before the caller's filter
+		s.metrics.Search(time.Since(start), len(merged))
 	}
-	return result
+	span.SetAttributes(tracing.AttrResultCount.Int(len(merged)))
+	logger.Info().
+		Int("merged", len(merged)).
+		Int("total", summary.Total).
+		Int64("duration_ms", summary.DurationMs).
+		Msg("streamed search complete")
+
+	return emit(model.SearchEvent{Type: model.SearchEventDone, Data: summary})
 }
 
-// sortByRelevance sorts results by match relevance to keyword
-// Priority: exact match > prefix match > contains match
-// Secondary: more sources > fewer sources
-// Tertiary: shorter name > longer name
-func (s *SearchService) sortByRelevance(items []model.VideoItem, keyword string) {
-	keywordLower := strings.ToLower(strings.TrimSpace(keyword))
-
-	sort.Slice(items, func(i, j int) bool {
-		scoreI := s.calculateRelevanceScore(items[i].VodName, keywordLower)
-		scoreJ := s.calculateRelevanceScore(items[j].VodName, keywordLower)
+// selectSources returns enabled sources filtered by the adult flag, the
+// requested source codes and the caller's profile
+func (s *SearchService) selectSources(opts SearchOptions) []config.SourceItem {
+	wanted := make(map[string]bool, len(opts.Sources))
+	for _, code := range opts.Sources {
+		wanted[code] = true
+	}
 
-		if scoreI != scoreJ {
-			return scoreI > scoreJ // Higher score first
+	var sources []config.SourceItem
+	for _, src := range s.config.GetEnabledSources() {
+		if src.Adult && !opts.IncludeAdult {
+			continue
 		}
-
-		// Same relevance, prefer more sources
-		if len(items[i].Sources) != len(items[j].Sources) {
-			return len(items[i].Sources) > len(items[j].Sources)
+		if len(wanted) > 0 && !wanted[src.Code] {
+			continue
+		}
+		if opts.Profile != nil && !opts.Profile.AllowsSource(src) {
+			continue
 		}
+		sources = append(sources, src)
+	}
+	return sources
+}
 
-		// Same sources count, prefer shorter name (more precise match)
-		return len(items[i].VodName) < len(items[j].VodName)
-	})
+// fanOut requests all sources concurrently for every keyword and delivers
+// results as they arrive, keeping only videos in categories the profile may see.
+// The returned channel is closed once every source has responded.
+func (s *SearchService) fanOut(ctx context.Context, sources []config.SourceItem, keywords []string, profile *config.Profile) <-chan sourceResult {
+	results := make(chan sourceResult, len(sources))
+	var wg sync.WaitGroup
+
+	// Concurrent requests to all sources
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src config.SourceItem) {
+			defer wg.Done()
+			logging.FromContext(ctx, s.logger).Info().Str("source", src.Code).Str("url", src.URL).Msg("requesting source")
+
+			ctx, span := tracer.Start(ctx, "search.source")
+			span.SetAttributes(tracing.AttrSourceCode.String(src.Code))
+
+			// The client applies the source timeout
+			start := time.Now()
+			list, err := s.searchSource(ctx, src, keywords)
+			list = filterCategories(list, profile)
+			tracing.End(span, err, tracing.AttrResultCount.Int(len(list)))
+			results <- sourceResult{source: src, list: list, err: err, latency: time.Since(start)}
+		}(src)
+	}
+
+	// Wait for all requests and close channel
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
 }
 
-// calculateRelevanceScore returns a score based on how well the name matches the keyword
-// Higher score = better match
-func (s *SearchService) calculateRelevanceScore(name, keywordLower string) int {
-	nameLower := strings.ToLower(strings.TrimSpace(name))
+// searchSource searches a source for each keyword, as one request to its
+// circuit breaker. Providers that cannot search several keywords at once are
+// searched for the first keyword, the query as typed.
+func (s *SearchService) searchSource(ctx context.Context, src config.SourceItem, keywords []string) ([]source.RawVideo, error) {
+	if searcher, ok := s.client.(source.KeywordSearcher); ok {
+		return searcher.SearchKeywords(ctx, src, keywords)
+	}
+	return s.client.Search(ctx, src, keywords[0])
+}
 
-	// Exact match (highest priority)
-	if nameLower == keywordLower {
-		return 100
+// filterCategories drops videos in categories the profile may not see.
+// The list is copied, as it may be shared with the source cache.
+func filterCategories(list []source.RawVideo, profile *config.Profile) []source.RawVideo {
+	if profile == nil || len(profile.Categories) == 0 {
+		return list
 	}
 
-	// Starts with keyword
-	if strings.HasPrefix(nameLower, keywordLower) {
-		return 80
+	filtered := make([]source.RawVideo, 0, len(list))
+	for _, v := range list {
+		if profile.AllowsCategory(v.TypeName) {
+			filtered = append(filtered, v)
+		}
 	}
+	return filtered
+}
 
-	// Check if keyword appears at the beginning of a "segment"
-	if strings.Contains(nameLower, keywordLower) {
-		// Bonus if keyword is at the start after common prefixes
-		idx := strings.Index(nameLower, keywordLower)
-		if idx == 0 {
-			return 80
+// status builds the status report of a source result
+func (r sourceResult) status() model.SourceStatus {
+	status := model.SourceStatus{
+		SourceCode: r.source.Code,
+		SourceName: r.source.Name,
+		Status:     model.SourceStatusOK,
+		Count:      len(r.list),
+		LatencyMs:  r.latency.Milliseconds(),
+	}
+
+	if r.err != nil {
+		status.Error = r.err.Error()
+		switch source.Classify(r.err) {
+		case source.ErrorClassTimeout:
+			status.Status = model.SourceStatusTimeout
+		case source.ErrorClassHTTPStatus:
+			status.Status = model.SourceStatusHTTPError
+		case source.ErrorClassDecode:
+			status.Status = model.SourceStatusDecodeError
+		case source.ErrorClassCircuit:
+			status.Status = model.SourceStatusCircuitOpen
+		default:
+			status.Status = model.SourceStatusError
 		}
-		// Earlier position = higher score
-		return 60 - idx
+	} else if len(r.list) == 0 {
+		status.Status = model.SourceStatusEmpty
 	}
 
-	// No match (shouldn't happen in search results, but just in case)
-	return 0
+	return status
+}
+
+// rankQuery describes a search for ranking its results. A year given in the
+// keyword, as in "沙丘 2021", favors titles from that year.
+func rankQuery(keyword string, keywords []string, sources int) ranking.Query {
+	year, _ := strconv.Atoi(yearPattern.FindString(keyword))
+	return ranking.Query{Keywords: keywords, Year: year, Sources: sources}
+}
+
+// rankResults merges search results and sorts them by relevance to the query
+// with the configured scorer
+func (s *SearchService) rankResults(raw []source.RawVideo, q ranking.Query) []model.VideoItem {
+	groups := s.mergeResults(raw)
+	ranking.Sort(s.scorer, q, groups, (*mergeGroup).candidate)
+
+	result := make([]model.VideoItem, 0, len(groups))
+	for _, g := range groups {
+		item := *g.item
+		item.VodYear = g.year
+		item.Kind = g.kind
+		result = append(result, item)
+	}
+	return result
 }
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/metrics"
+	"searchav/internal/model"
+	"searchav/internal/source"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeSource answers searches with fixed videos per source and records the
+// keywords each source was searched for
+type fakeSource struct {
+	videos map[string][]source.RawVideo
+
+	mu       sync.Mutex
+	keywords map[string][]string
+}
+
+func (f *fakeSource) Search(ctx context.Context, src config.SourceItem, keyword string) ([]source.RawVideo, error) {
+	return f.SearchKeywords(ctx, src, []string{keyword})
+}
+
+func (f *fakeSource) SearchKeywords(_ context.Context, src config.SourceItem, keywords []string) ([]source.RawVideo, error) {
+	f.mu.Lock()
+	f.keywords[src.Code] = append(f.keywords[src.Code], keywords...)
+	f.mu.Unlock()
+
+	list := make([]source.RawVideo, 0, len(f.videos[src.Code]))
+	for _, v := range f.videos[src.Code] {
+		v.SourceCode, v.SourceName = src.Code, src.Name
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (f *fakeSource) GetDetail(context.Context, config.SourceItem, int) (*source.RawVideo, error) {
+	return nil, source.ErrVideoNotFound
+}
+
+func (f *fakeSource) ListCategories(context.Context, config.SourceItem) ([]source.Category, error) {
+	return nil, nil
+}
+
+func (f *fakeSource) searched(code string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keywords[code]
+}
+
+func newTestSearch(t *testing.T, videos map[string][]source.RawVideo) (*SearchService, *fakeSource) {
+	t.Helper()
+	cfg := &config.Config{
+		Source: config.SourceConfig{Timeout: 5 * time.Second},
+		Sources: []config.SourceItem{
+			{Code: "pub", Name: "Public", Enabled: true},
+			{Code: "adult", Name: "Adult", Enabled: true, Adult: true},
+		},
+		Search: config.SearchConfig{Pinyin: config.PinyinConfig{Enabled: true, MaxTitles: 100, MaxCandidates: 5}},
+	}
+	fake := &fakeSource{videos: videos, keywords: make(map[string][]string)}
+	logger := zerolog.Nop()
+	svc, err := NewSearchService(cfg, fake, metrics.New(), &logger)
+	if err != nil {
+		t.Fatalf("NewSearchService: %v", err)
+	}
+	return svc, fake
+}
+
+// Titles seen on adult sources are not searched for on behalf of profiles
+// that may not see them
+func TestPinyinCandidatesFollowProfile(t *testing.T) {
+	svc, fake := newTestSearch(t, map[string][]source.RawVideo{
+		"pub":   {{VodID: 1, VodName: "进击的巨人", TypeName: "动漫"}},
+		"adult": {{VodID: 2, VodName: "禁忌的巨人", TypeName: "伦理"}},
+	})
+	ctx := context.Background()
+
+	adult := &config.Profile{Name: "vip", Adult: true}
+	if _, err := svc.Search(ctx, "进击的巨人", SearchOptions{IncludeAdult: true, Profile: adult}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	kid := &config.Profile{Name: "kid"}
+	if _, err := svc.Search(ctx, "jjdjr", SearchOptions{Profile: kid}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := fake.searched("pub"); !slices.Contains(got, "进击的巨人") || slices.Contains(got, "禁忌的巨人") {
+		t.Errorf("public source searched for %v, want 进击的巨人 but not the adult title", got)
+	}
+
+	// Category rules apply as well
+	movies := &config.Profile{Name: "movies", Adult: true, Categories: []string{"电影"}}
+	before := len(fake.searched("adult"))
+	if _, err := svc.Search(ctx, "jjdjr", SearchOptions{IncludeAdult: true, Profile: movies}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := fake.searched("adult")[before:]; slices.Contains(got, "禁忌的巨人") {
+		t.Errorf("adult source searched for %v, want no titles of hidden categories", got)
+	}
+}
+
+func TestKeywordsCapped(t *testing.T) {
+	videos := make([]source.RawVideo, 0, 8)
+	for i, name := range []string{"进击的巨人", "进击的巨人真人版", "禁忌的巨人", "进击的巨人剧场版", "进击的巨人前篇", "进击的巨人后篇"} {
+		videos = append(videos, source.RawVideo{VodID: i + 1, VodName: name})
+	}
+	svc, fake := newTestSearch(t, map[string][]source.RawVideo{"pub": videos})
+	ctx := context.Background()
+
+	if _, err := svc.Search(ctx, "进击的巨人", SearchOptions{}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	before := len(fake.searched("pub"))
+	if _, err := svc.Search(ctx, "jjdjr", SearchOptions{}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := fake.searched("pub")[before:]; len(got) != maxKeywords || got[0] != "jjdjr" {
+		t.Errorf("searched for %v, want the query and candidates up to %d keywords", got, maxKeywords)
+	}
+}
+
+// Streamed results follow the filter like Search, and the done event carries
+// the facets
+func TestSearchStreamFilter(t *testing.T) {
+	svc, _ := newTestSearch(t, map[string][]source.RawVideo{
+		"pub": {
+			{VodID: 1, VodName: "沙丘", VodYear: "2021", VodArea: "美国"},
+			{VodID: 2, VodName: "沙丘", VodYear: "1984", VodArea: "美国"},
+			{VodID: 3, VodName: "沙丘之子", VodYear: "2003", VodArea: "美国"},
+		},
+	})
+	filter := SearchFilter{Year: "2021"}
+
+	var last []model.VideoItem
+	var summary model.SearchSummary
+	err := svc.SearchStream(context.Background(), "沙丘", SearchOptions{Filter: filter}, func(ev model.SearchEvent) error {
+		switch ev.Type {
+		case model.SearchEventResults:
+			last = ev.Data.([]model.VideoItem)
+		case model.SearchEventDone:
+			summary = ev.Data.(model.SearchSummary)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchStream: %v", err)
+	}
+
+	if len(last) != 1 || last[0].VodYear != "2021" {
+		t.Errorf("last results = %+v, want the 2021 title only", last)
+	}
+	if summary.Total != 1 {
+		t.Errorf("summary total = %d, want 1", summary.Total)
+	}
+	if summary.Facets == nil || len(summary.Facets.Year) != 3 {
+		t.Fatalf("summary facets = %+v, want counts of all three years", summary.Facets)
+	}
+
+	// The facets match those of Search
+	result, err := svc.Search(context.Background(), "沙丘", SearchOptions{Filter: filter})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if !reflect.DeepEqual(result.Facets, summary.Facets) {
+		t.Errorf("stream facets = %+v, want %+v", summary.Facets, result.Facets)
+	}
+}
+
+// Sources answering together are ranked once into a single results event
+func TestSearchStreamBatches(t *testing.T) {
+	svc, _ := newTestSearch(t, map[string][]source.RawVideo{
+		"pub":   {{VodID: 1, VodName: "沙丘", VodYear: "2021"}},
+		"adult": {{VodID: 2, VodName: "沙丘", VodYear: "2021"}},
+	})
+
+	counts := make(map[string]int)
+	var last []model.VideoItem
+	err := svc.SearchStream(context.Background(), "沙丘", SearchOptions{IncludeAdult: true}, func(ev model.SearchEvent) error {
+		counts[ev.Type]++
+		if ev.Type == model.SearchEventResults {
+			last = ev.Data.([]model.VideoItem)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SearchStream: %v", err)
+	}
+
+	want := map[string]int{model.SearchEventSource: 2, model.SearchEventResults: 1, model.SearchEventDone: 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("events = %v, want %v", counts, want)
+	}
+	if len(last) != 1 || len(last[0].Sources) != 2 {
+		t.Errorf("results = %+v, want one title listed by both sources", last)
+	}
+}
+
+// countingSource counts searches and holds them until released
+type countingSource struct {
+	fakeSource
+	release chan struct{}
+	calls   atomic.Int32
+	leaked  atomic.Bool
+}
+
+func (f *countingSource) SearchKeywords(ctx context.Context, src config.SourceItem, keywords []string) ([]source.RawVideo, error) {
+	f.calls.Add(1)
+	if ctx.Value(callerKey{}) != nil {
+		f.leaked.Store(true)
+	}
+	<-f.release
+	return f.fakeSource.SearchKeywords(ctx, src, keywords)
+}
+
+// callerKey marks the context of a caller
+type callerKey struct{}
+
+// Concurrent identical searches make a single upstream call, which carries
+// none of the callers' context, and each caller's filter still applies
+func TestSearchCoalesced(t *testing.T) {
+	svc, _ := newTestSearch(t, nil)
+	fake := &countingSource{
+		fakeSource: fakeSource{
+			videos: map[string][]source.RawVideo{"pub": {
+				{VodID: 1, VodName: "沙丘", VodYear: "2021"},
+				{VodID: 2, VodName: "沙丘", VodYear: "1984"},
+			}},
+			keywords: make(map[string][]string),
+		},
+		release: make(chan struct{}),
+	}
+	svc.client = fake
+
+	years := []string{"", "2021", "1984", "2021"}
+	counts := make([]int, len(years))
+	var wg sync.WaitGroup
+	for i, year := range years {
+		wg.Add(1)
+		go func(i int, year string) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), callerKey{}, i)
+			result, err := svc.Search(ctx, "沙丘", SearchOptions{Filter: SearchFilter{Year: year}})
+			if err != nil {
+				t.Errorf("Search: %v", err)
+				return
+			}
+			counts[i] = len(result.List)
+		}(i, year)
+	}
+
+	// Release the upstream call once every caller has joined
+	for svc.Stats().Searches < uint64(len(years)) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(fake.release)
+	wg.Wait()
+
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+	if fake.leaked.Load() {
+		t.Error("upstream call carried a caller's context")
+	}
+	if want := []int{2, 1, 1, 1}; !slices.Equal(counts, want) {
+		t.Errorf("results per caller = %v, want %v", counts, want)
+	}
+	if stats := svc.Stats(); stats.Coalesced != uint64(len(years)-1) {
+		t.Errorf("coalesced = %d, want %d", stats.Coalesced, len(years)-1)
+	}
+}
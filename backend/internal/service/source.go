@@ -0,0 +1,62 @@
+package service
+
+import (
+	"searchav/internal/config"
+	"searchav/internal/model"
+	"searchav/internal/source"
+
+	"github.com/rs/zerolog"
+)
+
+// SourceService exposes the configured sources and their health
+type SourceService struct {
+	config *config.Config
+	client *source.Client
+	logger *zerolog.Logger
+}
+
+// NewSourceService creates a new source service
+func NewSourceService(cfg *config.Config, client *source.Client, logger *zerolog.Logger) *SourceService {
+	return &SourceService{
+		config: cfg,
+		client: client,
+		logger: logger,
+	}
+}
+
+// List returns every configured source the profile may use with its live
+// health status
+func (s *SourceService) List(profile *config.Profile) []model.SourceListItem {
+	sources := s.config.GetSources()
+	list := make([]model.SourceListItem, 0, len(sources))
+	for _, src := range sources {
+		if !profile.AllowsSource(src) {
+			continue
+		}
+
+		health := s.client.Health(src.Code)
+		list = append(list, model.SourceListItem{
+			Code:         src.Code,
+			Name:         src.Name,
+			Adult:        src.Adult,
+			Enabled:      src.Enabled,
+			Status:       health.State,
+			AvgLatencyMs: health.AvgLatencyMs,
+		})
+	}
+	return list
+}
+
+// Health returns the health and circuit breaker state of every configured
+// source the profile may use
+func (s *SourceService) Health(profile *config.Profile) []source.Health {
+	sources := s.config.GetSources()
+	list := make([]source.Health, 0, len(sources))
+	for _, src := range sources {
+		if !profile.AllowsSource(src) {
+			continue
+		}
+		list = append(list, s.client.Health(src.Code))
+	}
+	return list
+}
@@ -0,0 +1,187 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/constants"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// latencyWeight is the weight of the newest sample in the latency moving average
+const latencyWeight = 0.2
+
+// ErrCircuitOpen is returned when a source is skipped because its circuit is open
+var ErrCircuitOpen = fmt.Errorf("%w: source circuit open", constants.ErrUpstreamUnavailable)
+
+// Health is the health snapshot of a source
+type Health struct {
+	SourceCode          string     `json:"source_code"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Requests            uint64     `json:"requests"`
+	Failures            uint64     `json:"failures"`
+	AvgLatencyMs        int64      `json:"avg_latency_ms"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+}
+
+// breaker tracks the health of a single source
+type breaker struct {
+	state       string
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	requests    uint64
+	totalFailed uint64
+	avgLatency  float64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// healthTracker tracks failures and latency per source code and opens a
+// circuit after too many consecutive failures. Once the cooldown has passed
+// a single probe request is let through (half-open) to decide whether the
+// circuit closes again.
+type healthTracker struct {
+	mu       sync.Mutex
+	cfg      config.BreakerConfig
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+// newHealthTracker creates a health tracker
+func newHealthTracker(cfg config.BreakerConfig) *healthTracker {
+	return &healthTracker{
+		cfg:      cfg,
+		breakers: make(map[string]*breaker),
+		now:      time.Now,
+	}
+}
+
+// allow reports whether a request to the source may proceed
+func (t *healthTracker) allow(code string) error {
+	if !t.cfg.Enabled {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.get(code)
+	switch b.state {
+	case BreakerOpen:
+		if t.now().Sub(b.openedAt) < t.cfg.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record records the outcome of a request to the source
+func (t *healthTracker) record(code string, latency time.Duration, err error) {
+	// Requests aborted by the caller or never sent say nothing about the source
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		t.mu.Lock()
+		t.get(code).probing = false
+		t.mu.Unlock()
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.get(code)
+	b.requests++
+	b.probing = false
+
+	ms := float64(latency.Milliseconds())
+	if b.requests == 1 {
+		b.avgLatency = ms
+	} else {
+		b.avgLatency = b.avgLatency*(1-latencyWeight) + ms*latencyWeight
+	}
+
+	if err == nil || errors.Is(err, ErrVideoNotFound) {
+		b.failures = 0
+		b.state = BreakerClosed
+		return
+	}
+
+	b.failures++
+	b.totalFailed++
+	b.lastError = err.Error()
+	b.lastErrorAt = t.now()
+
+	if !t.cfg.Enabled {
+		return
+	}
+	if b.state == BreakerHalfOpen || b.failures >= t.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = t.now()
+	}
+}
+
+// reset forgets the health of the source
+func (t *healthTracker) reset(code string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.breakers, code)
+}
+
+// snapshot returns the health of the source
+func (t *healthTracker) snapshot(code string) Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.get(code)
+	h := Health{
+		SourceCode:          code,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Requests:            b.requests,
+		Failures:            b.totalFailed,
+		AvgLatencyMs:        int64(b.avgLatency),
+		LastError:           b.lastError,
+	}
+	if !b.lastErrorAt.IsZero() {
+		at := b.lastErrorAt
+		h.LastErrorAt = &at
+	}
+	if b.state == BreakerOpen {
+		at := b.openedAt.Add(t.cfg.Cooldown)
+		h.RetryAt = &at
+	}
+	return h
+}
+
+// get returns the breaker of a source, creating it if needed. Caller holds t.mu.
+func (t *healthTracker) get(code string) *breaker {
+	b, ok := t.breakers[code]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		t.breakers[code] = b
+	}
+	return b
+}
@@ -0,0 +1,168 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+)
+
+// breakerStep is a request to the source: whether the breaker lets it
+// through and, if so, its outcome and the resulting state
+type breakerStep struct {
+	after     time.Duration // Time passed since the previous step
+	wantAllow bool
+	err       error // Outcome of an allowed request
+	wantState string
+}
+
+func TestHealthTrackerStates(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name: "threshold trips",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerOpen},
+				{wantAllow: false, wantState: BreakerOpen},
+			},
+		},
+		{
+			name: "success resets the count",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "half open after cooldown and probe success closes",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure, wantState: BreakerOpen},
+				{after: 29 * time.Second, wantAllow: false, wantState: BreakerOpen},
+				{after: time.Second, wantAllow: true, wantState: BreakerClosed},
+				{wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "probe failure re-opens",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure, wantState: BreakerOpen},
+				{after: 30 * time.Second, wantAllow: true, err: failure, wantState: BreakerOpen},
+				{after: 29 * time.Second, wantAllow: false, wantState: BreakerOpen},
+				{after: time.Second, wantAllow: true, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "video not found counts as success",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: ErrVideoNotFound, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+				{wantAllow: true, err: failure, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "video not found closes a probe",
+			steps: []breakerStep{
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure},
+				{wantAllow: true, err: failure, wantState: BreakerOpen},
+				{after: 30 * time.Second, wantAllow: true, err: ErrVideoNotFound, wantState: BreakerClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, now := newTestTracker()
+			for i, step := range tt.steps {
+				*now = now.Add(step.after)
+				err := tracker.allow("src")
+				if allowed := err == nil; allowed != step.wantAllow {
+					t.Fatalf("step %d: allowed = %v, want %v", i, allowed, step.wantAllow)
+				}
+				if err == nil {
+					tracker.record("src", 10*time.Millisecond, step.err)
+				} else if !errors.Is(err, ErrCircuitOpen) {
+					t.Fatalf("step %d: allow error = %v, want ErrCircuitOpen", i, err)
+				}
+				if step.wantState == "" {
+					continue
+				}
+				if state := tracker.snapshot("src").State; state != step.wantState {
+					t.Fatalf("step %d: state = %s, want %s", i, state, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthTrackerSingleProbe(t *testing.T) {
+	tracker, now := newTestTracker()
+	for i := 0; i < 3; i++ {
+		tracker.record("src", 0, errors.New("timeout"))
+	}
+
+	*now = now.Add(30 * time.Second)
+	if err := tracker.allow("src"); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if state := tracker.snapshot("src").State; state != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half_open", state)
+	}
+
+	// Further requests wait for the probe's outcome
+	for i := 0; i < 3; i++ {
+		if err := tracker.allow("src"); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d during probe: %v, want ErrCircuitOpen", i, err)
+		}
+	}
+
+	// A cancelled probe says nothing about the source, the next request probes
+	tracker.record("src", 0, context.Canceled)
+	if err := tracker.allow("src"); err != nil {
+		t.Fatalf("probe after cancel: %v", err)
+	}
+	if err := tracker.allow("src"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second probe: %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestHealthTrackerDisabled(t *testing.T) {
+	tracker := newHealthTracker(config.BreakerConfig{Enabled: false, FailureThreshold: 1, Cooldown: time.Minute})
+	for i := 0; i < 5; i++ {
+		if err := tracker.allow("src"); err != nil {
+			t.Fatalf("allow: %v", err)
+		}
+		tracker.record("src", 0, errors.New("timeout"))
+	}
+
+	h := tracker.snapshot("src")
+	if h.State != BreakerClosed || h.ConsecutiveFailures != 5 || h.Failures != 5 {
+		t.Errorf("health = %+v, want closed with 5 failures", h)
+	}
+}
+
+// newTestTracker creates a tracker tripping after 3 failures for 30 seconds,
+// on a clock the test moves
+func newTestTracker() (*healthTracker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newHealthTracker(config.BreakerConfig{Enabled: true, FailureThreshold: 3, Cooldown: 30 * time.Second})
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
@@ -0,0 +1,169 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"searchav/internal/cache"
+	"searchav/internal/config"
+	"searchav/internal/logging"
+
+	"github.com/rs/zerolog"
+)
+
+// searchEntry is a cached search response, err is set for negative entries
+type searchEntry struct {
+	list []RawVideo
+	err  error
+}
+
+// detailEntry is a cached detail response, err is set for negative entries
+type detailEntry struct {
+	video *RawVideo
+	err   error
+}
+
+// CachedProvider caches search and detail responses of a Provider.
+// Search entries are keyed by source + keyword, detail entries by source + vodID.
+// Failed requests are cached for a shorter TTL so a failing source is not hammered.
+type CachedProvider struct {
+	next   Provider
+	cfg    config.CacheConfig
+	search *cache.Cache[string, searchEntry]
+	detail *cache.Cache[string, detailEntry]
+	logger *zerolog.Logger
+}
+
+// NewCachedProvider wraps the client with a response cache.
+// The client is returned unchanged when caching is disabled.
+func NewCachedProvider(cfg *config.Config, client *Client, logger *zerolog.Logger) Provider {
+	if !cfg.Cache.Enabled {
+		return client
+	}
+
+	return &CachedProvider{
+		next:   client,
+		cfg:    cfg.Cache,
+		search: cache.New[string, searchEntry](cfg.Cache.MaxEntries),
+		detail: cache.New[string, detailEntry](cfg.Cache.MaxEntries),
+		logger: logger,
+	}
+}
+
+// Search searches videos from a source, serving cached responses when available
+func (p *CachedProvider) Search(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
+	return p.cachedSearch(ctx, src, keyword, func() ([]RawVideo, error) {
+		return p.next.Search(ctx, src, keyword)
+	})
+}
+
+// SearchKeywords searches a source for several keywords at once, see
+// KeywordSearcher. The merged response is cached under all keywords.
+func (p *CachedProvider) SearchKeywords(ctx context.Context, src config.SourceItem, keywords []string) ([]RawVideo, error) {
+	searcher, ok := p.next.(KeywordSearcher)
+	if !ok || len(keywords) == 1 {
+		return p.Search(ctx, src, keywords[0])
+	}
+
+	return p.cachedSearch(ctx, src, strings.Join(keywords, "\x00"), func() ([]RawVideo, error) {
+		return searcher.SearchKeywords(ctx, src, keywords)
+	})
+}
+
+// cachedSearch serves a search from the cache or runs it and caches its response
+func (p *CachedProvider) cachedSearch(ctx context.Context, src config.SourceItem, keyword string, search func() ([]RawVideo, error)) ([]RawVideo, error) {
+	logger := logging.FromContext(ctx, p.logger)
+	key := src.Code + "\x00" + strings.TrimSpace(keyword)
+
+	if e, ok := p.search.Get(key); ok {
+		logger.Info().
+			Str("source", src.Code).
+			Str("keyword", keyword).
+			Bool("cache_hit", true).
+			Bool("negative", e.err != nil).
+			Msg("search cache")
+		return e.list, e.err
+	}
+
+	list, err := search()
+	if ttl := p.ttl(err, p.cfg.SearchTTL); ttl > 0 {
+		p.search.Set(key, searchEntry{list: list, err: err}, ttl)
+	}
+
+	logger.Info().
+		Str("source", src.Code).
+		Str("keyword", keyword).
+		Bool("cache_hit", false).
+		Msg("search cache")
+
+	return list, err
+}
+
+// GetDetail gets video detail from a source, serving cached responses when available
+func (p *CachedProvider) GetDetail(ctx context.Context, src config.SourceItem, vodID int) (*RawVideo, error) {
+	logger := logging.FromContext(ctx, p.logger)
+	key := src.Code + "\x00" + strconv.Itoa(vodID)
+
+	if e, ok := p.detail.Get(key); ok {
+		logger.Info().
+			Str("source", src.Code).
+			Int("vod_id", vodID).
+			Bool("cache_hit", true).
+			Bool("negative", e.err != nil).
+			Msg("detail cache")
+		return e.video, e.err
+	}
+
+	video, err := p.next.GetDetail(ctx, src, vodID)
+	if ttl := p.ttl(err, p.cfg.DetailTTL); ttl > 0 {
+		p.detail.Set(key, detailEntry{video: video, err: err}, ttl)
+	}
+
+	logger.Info().
+		Str("source", src.Code).
+		Int("vod_id", vodID).
+		Bool("cache_hit", false).
+		Msg("detail cache")
+
+	return video, err
+}
+
+// ListCategories lists the categories of a source without caching
+func (p *CachedProvider) ListCategories(ctx context.Context, src config.SourceItem) ([]Category, error) {
+	return p.next.ListCategories(ctx, src)
+}
+
+// Invalidate drops the cached responses of a source, after its endpoint
+// changed or it was removed
+func (p *CachedProvider) Invalidate(code string) {
+	prefix := code + "\x00"
+	hasPrefix := func(key string) bool { return strings.HasPrefix(key, prefix) }
+
+	removed := p.search.DeleteFunc(hasPrefix) + p.detail.DeleteFunc(hasPrefix)
+	p.logger.Info().Str("source", code).Int("entries", removed).Msg("source cache invalidated")
+}
+
+// SearchStats returns the search cache usage counters
+func (p *CachedProvider) SearchStats() cache.Stats {
+	return p.search.Stats()
+}
+
+// DetailStats returns the detail cache usage counters
+func (p *CachedProvider) DetailStats() cache.Stats {
+	return p.detail.Stats()
+}
+
+// ttl returns how long a response may be cached.
+// Requests aborted by the caller or skipped by the circuit breaker are never cached.
+func (p *CachedProvider) ttl(err error, ttl time.Duration) time.Duration {
+	if err == nil {
+		return ttl
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return 0
+	}
+	return p.cfg.ErrorTTL
+}
@@ -0,0 +1,68 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCachedProviderInvalidate(t *testing.T) {
+	client := newTestClient()
+	client.config.Cache = config.CacheConfig{
+		Enabled:    true,
+		SearchTTL:  time.Minute,
+		DetailTTL:  time.Minute,
+		MaxEntries: 100,
+	}
+	fake := &fakeProvider{list: []RawVideo{{VodID: 1, VodName: "a"}}}
+	client.Register(TypeMacCMSJSON, fake)
+	logger := zerolog.Nop()
+	p := NewCachedProvider(client.config, client, &logger).(*CachedProvider)
+
+	ctx := context.Background()
+	a := config.SourceItem{Code: "a"}
+	b := config.SourceItem{Code: "b"}
+	// A source whose code extends another one must keep its entries
+	ab := config.SourceItem{Code: "a2"}
+	for _, src := range []config.SourceItem{a, b, ab} {
+		_, _ = p.Search(ctx, src, "kw")
+		_, _ = p.GetDetail(ctx, src, 1)
+	}
+	calls := len(fake.calls)
+
+	p.Invalidate("a")
+
+	for _, src := range []config.SourceItem{a, b, ab} {
+		_, _ = p.Search(ctx, src, "kw")
+		_, _ = p.GetDetail(ctx, src, 1)
+	}
+	if got := len(fake.calls) - calls; got != 2 {
+		t.Fatalf("%d requests after invalidating a, want 2 (search and detail of a): %v", got, fake.calls[calls:])
+	}
+	for _, call := range fake.calls[calls:] {
+		if call != "a:kw" && call != "a" {
+			t.Errorf("request %q after invalidating a, want only requests to a", call)
+		}
+	}
+}
+
+func TestClientResetHealth(t *testing.T) {
+	c := newTestClient()
+	c.health = newHealthTracker(config.BreakerConfig{Enabled: true, FailureThreshold: 1, Cooldown: time.Minute})
+	c.Register(TypeMacCMSJSON, &fakeProvider{err: &StatusError{StatusCode: 500}})
+	src := config.SourceItem{Code: "a"}
+
+	_, _ = c.Search(context.Background(), src, "kw")
+	if state := c.Health("a").State; state != BreakerOpen {
+		t.Fatalf("state after failure = %s, want %s", state, BreakerOpen)
+	}
+
+	c.ResetHealth("a")
+	if h := c.Health("a"); h.State != BreakerClosed || h.Requests != 0 {
+		t.Errorf("health after reset = %+v, want a closed circuit without history", h)
+	}
+}
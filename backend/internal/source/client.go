@@ -4,101 +4,314 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"searchav/internal/config"
+	"searchav/internal/logging"
+	"searchav/internal/metrics"
+	"searchav/internal/tracing"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	apiPath = "/provide/vod/at/json"
-)
+var tracer = otel.Tracer("searchav/internal/source")
 
-// Client is the video source API client
+// Client is the video source API client.
+// It dispatches each request to the Provider registered for the source type.
 type Client struct {
-	http   *resty.Client
-	logger *zerolog.Logger
+	config    *config.Config
+	http      *resty.Client
+	fetchHTTP *resty.Client // Guarded client of Fetch, see newFetchClient
+	logger    *zerolog.Logger
+	providers map[string]Provider
+	health    *healthTracker
+	metrics   *metrics.Metrics
 }
 
-// NewClient creates a new source client
-func NewClient(cfg *config.Config, logger *zerolog.Logger) *Client {
+// NewClient creates a new source client with the built-in adapters registered
+func NewClient(cfg *config.Config, m *metrics.Metrics, logger *zerolog.Logger) *Client {
+	// Timeouts are applied per request from the live config, see withTimeout
 	client := resty.New().
-		SetTimeout(cfg.Source.Timeout).
 		SetRetryCount(cfg.Source.Retry).
 		SetHeader("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 
-	return &Client{
-		http:   client,
-		logger: logger,
+	c := &Client{
+		config:    cfg,
+		http:      client,
+		fetchHTTP: newFetchClient(cfg),
+		logger:    logger,
+		providers: make(map[string]Provider),
+		health:    newHealthTracker(cfg.Source.Breaker),
+		metrics:   m,
+	}
+
+	c.Register(TypeMacCMSJSON, newMacCMSJSON(client, logger))
+	c.Register(TypeMacCMSXML, newMacCMSXML(client, logger))
+
+	return c
+}
+
+// Register registers a provider for a source type, replacing any existing one
+func (c *Client) Register(typ string, p Provider) {
+	c.providers[typ] = p
+}
+
+// Supports reports whether a provider is registered for the source type
+func (c *Client) Supports(typ string) bool {
+	if typ == "" {
+		typ = DefaultType
+	}
+	_, ok := c.providers[typ]
+	return ok
+}
+
+// Probe sends a search to a source without circuit breaking or health
+// tracking, to check that a new or changed source works
+func (c *Client) Probe(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
+	p, err := c.provider(src)
+	if err != nil {
+		return nil, err
 	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return p.Search(ctx, src, keyword)
 }
 
 // Search searches videos from a source
-func (c *Client) Search(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
-	reqURL := fmt.Sprintf("%s%s?ac=videolist&wd=%s", src.URL, apiPath, url.QueryEscape(keyword))
+func (c *Client) Search(ctx context.Context, src config.SourceItem, keyword string) (list []RawVideo, err error) {
+	ctx, span := startSpan(ctx, metrics.OpSearch, src)
+	defer func() { endSpan(span, len(list), err) }()
+
+	p, err := c.provider(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.allow(src.Code, metrics.OpSearch); err != nil {
+		logging.FromContext(ctx, c.logger).Debug().Str("source", src.Code).Msg("source skipped, circuit open")
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	list, err = p.Search(ctx, src, keyword)
+	c.record(src.Code, metrics.OpSearch, time.Since(start), err)
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+
+	// Inject source info
+	for i := range list {
+		list[i].SourceCode = src.Code
+		list[i].SourceName = src.Name
+	}
+
+	return list, nil
+}
 
-	c.logger.Info().Str("url", reqURL).Str("source", src.Code).Msg("search request")
+// SearchKeywords searches a source for each keyword concurrently under one
+// timeout. It only fails when every keyword fails, and is recorded as a
+// single request for health tracking.
+func (c *Client) SearchKeywords(ctx context.Context, src config.SourceItem, keywords []string) (list []RawVideo, err error) {
+	if len(keywords) == 1 {
+		return c.Search(ctx, src, keywords[0])
+	}
 
-	var resp MacCMSResponse
-	httpResp, err := c.http.R().
-		SetContext(ctx).
-		ForceContentType("application/json").
-		SetResult(&resp).
-		Get(reqURL)
+	ctx, span := startSpan(ctx, metrics.OpSearch, src)
+	defer func() { endSpan(span, len(list), err) }()
 
+	p, err := c.provider(src)
 	if err != nil {
-		c.logger.Error().Err(err).Str("source", src.Code).Msg("search request failed")
 		return nil, err
 	}
 
-	c.logger.Info().
-		Str("source", src.Code).
-		Int("status", httpResp.StatusCode()).
-		Int("code", resp.Code).
-		Str("msg", resp.Msg).
-		Int("total", resp.Total).
-		Int("count", len(resp.List)).
-		Msg("search response")
+	if err := c.allow(src.Code, metrics.OpSearch); err != nil {
+		logging.FromContext(ctx, c.logger).Debug().Str("source", src.Code).Msg("source skipped, circuit open")
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	lists := make([][]RawVideo, len(keywords))
+	errs := make([]error, len(keywords))
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i, keyword := range keywords {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lists[i], errs[i] = p.Search(ctx, src, keyword)
+		}()
+	}
+	wg.Wait()
+
+	list, err = mergeKeywordResults(lists, errs)
+	c.record(src.Code, metrics.OpSearch, time.Since(start), err)
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
 
 	// Inject source info
-	for i := range resp.List {
-		resp.List[i].SourceCode = src.Code
-		resp.List[i].SourceName = src.Name
+	for i := range list {
+		list[i].SourceCode = src.Code
+		list[i].SourceName = src.Name
 	}
 
-	return resp.List, nil
+	return list, nil
+}
+
+// mergeKeywordResults merges the results of searching a source for several
+// keywords, keeping videos found by several keywords once. It fails with the
+// first error only when every keyword failed.
+func mergeKeywordResults(lists [][]RawVideo, errs []error) ([]RawVideo, error) {
+	var merged []RawVideo
+	seen := make(map[int]bool)
+	failed := 0
+	for i := range lists {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		for _, v := range lists[i] {
+			if !seen[v.VodID] {
+				seen[v.VodID] = true
+				merged = append(merged, v)
+			}
+		}
+	}
+	if failed == len(errs) {
+		return nil, errs[0]
+	}
+	return merged, nil
 }
 
 // GetDetail gets video detail from a source
-func (c *Client) GetDetail(ctx context.Context, src config.SourceItem, vodID int) (*RawVideo, error) {
-	reqURL := fmt.Sprintf("%s%s?ac=videolist&ids=%d", src.URL, apiPath, vodID)
+func (c *Client) GetDetail(ctx context.Context, src config.SourceItem, vodID int) (raw *RawVideo, err error) {
+	ctx, span := startSpan(ctx, metrics.OpDetail, src)
+	defer func() { endSpan(span, 1, err) }()
+
+	p, err := c.provider(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.allow(src.Code, metrics.OpDetail); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	raw, err = p.GetDetail(ctx, src, vodID)
+	c.record(src.Code, metrics.OpDetail, time.Since(start), err)
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+
+	raw.SourceCode = src.Code
+	raw.SourceName = src.Name
 
-	c.logger.Debug().Str("url", reqURL).Str("source", src.Code).Msg("detail request")
+	return raw, nil
+}
 
-	var resp MacCMSResponse
-	_, err := c.http.R().
-		SetContext(ctx).
-		ForceContentType("application/json").
-		SetResult(&resp).
-		Get(reqURL)
+// ListCategories lists the categories of a source
+func (c *Client) ListCategories(ctx context.Context, src config.SourceItem) (categories []Category, err error) {
+	ctx, span := startSpan(ctx, metrics.OpCategories, src)
+	defer func() { endSpan(span, len(categories), err) }()
 
+	p, err := c.provider(src)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.allow(src.Code, metrics.OpCategories); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	categories, err = p.ListCategories(ctx, src)
+	c.record(src.Code, metrics.OpCategories, time.Since(start), err)
+	return categories, wrapTimeout(err)
+}
+
+// Health returns the health and circuit breaker state of a source
+func (c *Client) Health(code string) Health {
+	return c.health.snapshot(code)
+}
+
+// ResetHealth forgets the health and circuit breaker state of a source, after
+// its endpoint changed or it was removed
+func (c *Client) ResetHealth(code string) {
+	c.health.reset(code)
+}
+
+// allow checks the circuit breaker of a source, counting skipped requests
+func (c *Client) allow(code, operation string) error {
+	err := c.health.allow(code)
+	if err != nil {
+		c.metrics.SourceRequest(code, operation, ErrorClassCircuit)
+	}
+	return err
+}
+
+// record updates health tracking and metrics after a request to a source
+func (c *Client) record(code, operation string, latency time.Duration, err error) {
+	c.health.record(code, latency, err)
+
+	class := ""
+	if err != nil {
+		class = Classify(err)
+	}
+	c.metrics.SourceRequest(code, operation, class)
+	c.metrics.SourceLatency(code, operation, latency)
+}
 
-	if len(resp.List) == 0 {
-		return nil, fmt.Errorf("video not found")
+// startSpan starts the client span of a request to a source
+func startSpan(ctx context.Context, operation string, src config.SourceItem) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{tracing.AttrSourceCode.String(src.Code)}
+	if u, err := url.Parse(src.URL); err == nil {
+		attrs = append(attrs, attribute.String("server.address", u.Host))
 	}
+	return tracer.Start(ctx, "source."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
 
-	return &resp.List[0], nil
+// endSpan records the result count or error class of a source request
+func endSpan(span trace.Span, count int, err error) {
+	if err != nil {
+		tracing.End(span, err, tracing.AttrErrorClass.String(Classify(err)))
+		return
+	}
+	tracing.End(span, nil, tracing.AttrResultCount.Int(count))
 }
 
-// SearchWithTimeout searches with a timeout
-func (c *Client) SearchWithTimeout(ctx context.Context, src config.SourceItem, keyword string, timeout time.Duration) ([]RawVideo, error) {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// withTimeout bounds a request by the configured source timeout
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.config.SourceTimeout())
+}
 
-	return c.Search(ctx, src, keyword)
+// provider returns the provider registered for the source type
+func (c *Client) provider(src config.SourceItem) (Provider, error) {
+	typ := src.Type
+	if typ == "" {
+		typ = DefaultType
+	}
+
+	p, ok := c.providers[typ]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source type %q for source %s", typ, src.Code)
+	}
+	return p, nil
 }
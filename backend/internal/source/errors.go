@@ -0,0 +1,68 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"searchav/internal/constants"
+)
+
+// Error classes reported by Classify
+const (
+	ErrorClassTimeout    = "timeout"
+	ErrorClassHTTPStatus = "http_status"
+	ErrorClassDecode     = "decode_error"
+	ErrorClassCircuit    = "circuit_open"
+	ErrorClassOther      = "error"
+)
+
+var (
+	// ErrDecode indicates the upstream payload could not be decoded
+	ErrDecode = constants.ErrUpstreamBadPayload
+	// ErrVideoNotFound indicates the source has no video with the requested ID
+	ErrVideoNotFound = constants.ErrVideoNotFound
+)
+
+// StatusError is returned when the upstream answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream returned http status %d", e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return constants.ErrUpstreamError
+}
+
+// Classify returns the error class of a source request error
+func Classify(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorClassCircuit
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &statusErr):
+		return ErrorClassHTTPStatus
+	case errors.Is(err, ErrDecode):
+		return ErrorClassDecode
+	default:
+		return ErrorClassOther
+	}
+}
+
+// wrapTimeout tags a timed out source request with constants.ErrUpstreamTimeout
+func wrapTimeout(err error) error {
+	if err != nil && Classify(err) == ErrorClassTimeout {
+		return fmt.Errorf("%w: %w", constants.ErrUpstreamTimeout, err)
+	}
+	return err
+}
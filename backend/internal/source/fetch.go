@@ -0,0 +1,148 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/constants"
+	"searchav/internal/logging"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// maxFetchRedirects is the number of redirects Fetch follows
+const maxFetchRedirects = 5
+
+// ErrFetchRefused is returned when Fetch may not reach a URL, either because
+// its host is not allowed or because it resolves to a non-public address
+var ErrFetchRefused = fmt.Errorf("%w: fetch refused", constants.ErrForbidden)
+
+// cgnat is the shared address space of carrier-grade NAT, not covered by
+// netip.Addr.IsPrivate
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// newFetchClient creates the HTTP client of Fetch. Fetched URLs come from
+// callers, so the client must not become a way into the internal network:
+// its dialer refuses non-public addresses after DNS resolution, which also
+// covers redirects and hosts resolving to internal addresses, and redirects
+// must stay on the allowed hosts. Proxies are not used, as the dialer would
+// only see the proxy address.
+func newFetchClient(cfg *config.Config) *resty.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !cfg.HLS.AllowPrivateNetworks {
+		dialer.Control = refusePrivate
+	}
+
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+
+	allowed := cfg.HLS.AllowedHosts
+	return resty.New().
+		SetTransport(transport).
+		SetRetryCount(cfg.Source.Retry).
+		SetHeader("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36").
+		SetRedirectPolicy(resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if !hostAllowed(allowed, req.URL.Hostname()) {
+				return fmt.Errorf("%w: redirect to %s", ErrFetchRefused, req.URL.Host)
+			}
+			return nil
+		}))
+}
+
+// Fetch fetches a raw resource over the guarded fetch client. Only hosts in
+// hls.allowed_hosts are fetched when it is set, and never non-public
+// addresses unless hls.allow_private_networks is set.
+func (c *Client) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	logging.FromContext(ctx, c.logger).Debug().Str("url", rawURL).Msg("fetch request")
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if !hostAllowed(c.config.HLS.AllowedHosts, u.Hostname()) {
+		return nil, fmt.Errorf("%w: host %s", ErrFetchRefused, u.Host)
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	httpResp, err := c.fetchHTTP.R().
+		SetContext(ctx).
+		Get(rawURL)
+
+	if errors.Is(err, ErrFetchRefused) {
+		// Refused on dial or redirect, keep the transport details out
+		return nil, fmt.Errorf("%w: %s", ErrFetchRefused, u.Host)
+	}
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+
+	if httpResp.IsError() {
+		return nil, &StatusError{StatusCode: httpResp.StatusCode()}
+	}
+
+	return httpResp.Body(), nil
+}
+
+// hostAllowed reports whether a host is one of the allowed hosts or their
+// subdomains, any host being allowed when the list is empty
+func hostAllowed(allowed []string, host string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSuffix(a, "."))
+		if host == a || strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
+
+// refusePrivate is a dialer control function refusing connections to
+// loopback, private, link-local, multicast and unspecified addresses
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addr) {
+		return fmt.Errorf("%w: address %s is not public", ErrFetchRefused, addr)
+	}
+	return nil
+}
+
+// isPublic reports whether an address is routable on the internet
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!cgnat.Contains(addr) &&
+		!(addr.Is4() && addr.As4()[0] == 0)
+}
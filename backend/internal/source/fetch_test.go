@@ -0,0 +1,112 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+func newFetchTestClient(hls config.HLSConfig) *Client {
+	cfg := &config.Config{HLS: hls}
+	cfg.Source.Timeout = 5 * time.Second
+	logger := zerolog.Nop()
+	return NewClient(cfg, metrics.New(), &logger)
+}
+
+func TestFetchGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Same server under another host name
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/index.m3u8", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		hls     config.HLSConfig
+		path    string
+		refused bool
+	}{
+		{"loopback refused", config.HLSConfig{}, "/index.m3u8", true},
+		{"loopback allowed", config.HLSConfig{AllowPrivateNetworks: true}, "/index.m3u8", false},
+		{"host not allowed", config.HLSConfig{AllowPrivateNetworks: true, AllowedHosts: []string{"cdn.example.com"}}, "/index.m3u8", true},
+		{"host allowed", config.HLSConfig{AllowPrivateNetworks: true, AllowedHosts: []string{"127.0.0.1"}}, "/index.m3u8", false},
+		{"redirect off allowed hosts", config.HLSConfig{AllowPrivateNetworks: true, AllowedHosts: []string{"127.0.0.1"}}, "/redirect", true},
+		{"redirect within allowed hosts", config.HLSConfig{AllowPrivateNetworks: true, AllowedHosts: []string{"127.0.0.1", "localhost"}}, "/redirect", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFetchTestClient(tt.hls)
+			body, err := c.Fetch(context.Background(), srv.URL+tt.path)
+			if tt.refused {
+				if !errors.Is(err, ErrFetchRefused) {
+					t.Fatalf("Fetch error = %v, want ErrFetchRefused", err)
+				}
+				return
+			}
+			if err != nil || string(body) != "#EXTM3U\n" {
+				t.Fatalf("Fetch = %q, %v, want the playlist", body, err)
+			}
+		})
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":            true,
+		"2606:4700::1111":    true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"100.64.0.1":         false,
+		"0.0.0.0":            false,
+		"0.1.2.3":            false,
+		"224.0.0.1":          false,
+		"::ffff:127.0.0.1":   false,
+		"::ffff:169.254.1.1": false,
+	}
+	for addr, want := range tests {
+		if got := isPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"cdn.example.com", "Play.Example.org."}
+	tests := map[string]bool{
+		"cdn.example.com":      true,
+		"a.cdn.example.com":    true,
+		"play.example.org":     true,
+		"example.com":          false,
+		"evilcdn.example.com":  false,
+		"cdn.example.com.evil": false,
+	}
+	for host, want := range tests {
+		if got := hostAllowed(allowed, host); got != want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", host, got, want)
+		}
+	}
+	if !hostAllowed(nil, "anything.example") {
+		t.Error("hostAllowed with no list refused a host")
+	}
+}
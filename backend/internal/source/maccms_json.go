@@ -0,0 +1,115 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"searchav/internal/config"
+	"searchav/internal/logging"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog"
+)
+
+const (
+	jsonAPIPath = "/provide/vod/at/json"
+)
+
+// macCMSJSON is the adapter for the MacCMS v10 JSON API
+type macCMSJSON struct {
+	http   *resty.Client
+	logger *zerolog.Logger
+}
+
+// newMacCMSJSON creates a MacCMS JSON adapter
+func newMacCMSJSON(http *resty.Client, logger *zerolog.Logger) *macCMSJSON {
+	return &macCMSJSON{
+		http:   http,
+		logger: logger,
+	}
+}
+
+// Search searches videos from a source
+func (p *macCMSJSON) Search(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
+	logger := logging.FromContext(ctx, p.logger)
+	reqURL := buildURL(src, jsonAPIPath, url.Values{"ac": {"videolist"}, "wd": {keyword}})
+
+	logger.Info().Str("url", reqURL).Str("source", src.Code).Msg("search request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		logger.Error().Err(err).Str("source", src.Code).Msg("search request failed")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("source", src.Code).
+		Int("code", resp.Code).
+		Str("msg", resp.Msg).
+		Int("total", resp.Total).
+		Int("count", len(resp.List)).
+		Msg("search response")
+
+	return resp.List, nil
+}
+
+// GetDetail gets video detail from a source
+func (p *macCMSJSON) GetDetail(ctx context.Context, src config.SourceItem, vodID int) (*RawVideo, error) {
+	reqURL := buildURL(src, jsonAPIPath, url.Values{"ac": {"videolist"}, "ids": {strconv.Itoa(vodID)}})
+
+	logging.FromContext(ctx, p.logger).Debug().Str("url", reqURL).Str("source", src.Code).Msg("detail request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.List) == 0 {
+		return nil, ErrVideoNotFound
+	}
+
+	return &resp.List[0], nil
+}
+
+// ListCategories lists the categories of a source
+func (p *macCMSJSON) ListCategories(ctx context.Context, src config.SourceItem) ([]Category, error) {
+	reqURL := buildURL(src, jsonAPIPath, url.Values{"ac": {"list"}})
+
+	logging.FromContext(ctx, p.logger).Debug().Str("url", reqURL).Str("source", src.Code).Msg("category request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Class, nil
+}
+
+// fetch requests a JSON API URL and decodes the response
+func (p *macCMSJSON) fetch(ctx context.Context, reqURL string) (*MacCMSResponse, error) {
+	httpResp, err := p.http.R().
+		SetContext(ctx).
+		Get(reqURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.IsError() {
+		return nil, &StatusError{StatusCode: httpResp.StatusCode()}
+	}
+
+	var resp MacCMSResponse
+	if err := json.Unmarshal(httpResp.Body(), &resp); err != nil {
+		return nil, fmt.Errorf("%w: maccms json: %v", ErrDecode, err)
+	}
+	return &resp, nil
+}
+
+// buildURL joins the source base URL, API path and query parameters
+func buildURL(src config.SourceItem, path string, params url.Values) string {
+	return fmt.Sprintf("%s%s?%s", src.URL, path, params.Encode())
+}
@@ -0,0 +1,184 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+	"searchav/internal/metrics"
+
+	"github.com/rs/zerolog"
+)
+
+// fixtureServer serves testdata files by API path and ac parameter. The
+// search fixtures answer both videolist and list requests, as MacCMS does.
+func fixtureServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient() *Client {
+	cfg := &config.Config{}
+	cfg.Source.Timeout = 5 * time.Second
+	logger := zerolog.Nop()
+	return NewClient(cfg, metrics.New(), &logger)
+}
+
+func TestMacCMSAdapters(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		fixture map[string]string
+		want    []RawVideo
+		classes []Category
+	}{
+		{
+			name:    "json",
+			typ:     TypeMacCMSJSON,
+			fixture: map[string]string{jsonAPIPath: "search.json"},
+			want: []RawVideo{
+				{
+					VodID:       101,
+					VodName:     "进击的巨人",
+					VodPic:      "https://img.example.com/101.jpg",
+					VodRemarks:  "全25集",
+					TypeName:    "日本动漫",
+					VodPlayFrom: "m3u8$$$share",
+					VodPlayURL:  "第01集$https://play.example.com/101/1.m3u8#第02集$https://play.example.com/101/2.m3u8$$$第01集$https://share.example.com/101/1",
+					VodContent:  "<p>人类与巨人的战斗。</p>",
+					VodYear:     "2013",
+					VodArea:     "日本",
+					VodDirector: "荒木哲郎",
+					VodActor:    "梶裕贵,石川由依",
+				},
+				{
+					VodID:      102,
+					VodName:    "进击的巨人 第二季",
+					VodPic:     "https://img.example.com/102.jpg",
+					VodRemarks: "全12集",
+					TypeName:   "日本动漫",
+					VodYear:    "2017",
+					VodArea:    "日本",
+				},
+			},
+			classes: []Category{{TypeID: 1, TypeName: "电影"}, {TypeID: 4, TypeName: "动漫"}},
+		},
+		{
+			name:    "xml",
+			typ:     TypeMacCMSXML,
+			fixture: map[string]string{xmlAPIPath: "search.xml"},
+			want: []RawVideo{
+				{
+					VodID:       201,
+					VodName:     "流浪地球",
+					VodPic:      "https://img.example.com/201.jpg",
+					VodRemarks:  "HD",
+					TypeName:    "科幻片",
+					VodPlayFrom: "m3u8$$$mp4",
+					VodPlayURL:  "正片$https://play.example.com/201/index.m3u8$$$正片$https://play.example.com/201/movie.mp4",
+					VodContent:  "太阳即将毁灭。",
+					VodYear:     "2019",
+					VodArea:     "大陆",
+					VodDirector: "郭帆",
+					VodActor:    "吴京,屈楚萧",
+				},
+				{
+					VodID:    202,
+					VodName:  "流浪地球2",
+					TypeName: "科幻片",
+					VodYear:  "2023",
+				},
+			},
+			classes: []Category{{TypeID: 1, TypeName: "电影"}, {TypeID: 2, TypeName: "连续剧"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fixtureServer(t, tt.fixture)
+			c := newTestClient()
+			src := config.SourceItem{Code: "test", Name: "Test", URL: srv.URL, Type: tt.typ, Enabled: true}
+			ctx := context.Background()
+
+			list, err := c.Search(ctx, src, "keyword")
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(list) != len(tt.want) {
+				t.Fatalf("Search returned %d videos, want %d", len(list), len(tt.want))
+			}
+			for i, want := range tt.want {
+				want.SourceCode, want.SourceName = "test", "Test"
+				if list[i] != want {
+					t.Errorf("video %d =\n%+v\nwant\n%+v", i, list[i], want)
+				}
+			}
+
+			detail, err := c.GetDetail(ctx, src, tt.want[0].VodID)
+			if err != nil {
+				t.Fatalf("GetDetail: %v", err)
+			}
+			if detail.VodID != tt.want[0].VodID || detail.SourceCode != "test" {
+				t.Errorf("GetDetail = %d from %q, want %d from test", detail.VodID, detail.SourceCode, tt.want[0].VodID)
+			}
+
+			classes, err := c.ListCategories(ctx, src)
+			if err != nil {
+				t.Fatalf("ListCategories: %v", err)
+			}
+			if len(classes) != len(tt.classes) {
+				t.Fatalf("ListCategories returned %v, want %v", classes, tt.classes)
+			}
+			for i := range classes {
+				if classes[i] != tt.classes[i] {
+					t.Errorf("category %d = %+v, want %+v", i, classes[i], tt.classes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMacCMSAdapterErrors(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{
+		jsonAPIPath: "empty.json",
+		xmlAPIPath:  "broken.json",
+	})
+	c := newTestClient()
+	ctx := context.Background()
+	jsonSrc := config.SourceItem{Code: "json", URL: srv.URL, Type: TypeMacCMSJSON}
+	xmlSrc := config.SourceItem{Code: "xml", URL: srv.URL, Type: TypeMacCMSXML}
+	missing := config.SourceItem{Code: "missing", URL: srv.URL + "/missing", Type: TypeMacCMSJSON}
+
+	if list, err := c.Search(ctx, jsonSrc, "keyword"); err != nil || len(list) != 0 {
+		t.Errorf("Search on empty list = %v, %v, want no videos and no error", list, err)
+	}
+	if _, err := c.GetDetail(ctx, jsonSrc, 1); !errors.Is(err, ErrVideoNotFound) {
+		t.Errorf("GetDetail on empty list error = %v, want ErrVideoNotFound", err)
+	}
+	if _, err := c.Search(ctx, xmlSrc, "keyword"); Classify(err) != ErrorClassDecode {
+		t.Errorf("Search on HTML payload error = %v, want class %s", err, ErrorClassDecode)
+	}
+	if _, err := c.Search(ctx, missing, "keyword"); Classify(err) != ErrorClassHTTPStatus {
+		t.Errorf("Search on 404 error = %v, want class %s", err, ErrorClassHTTPStatus)
+	}
+}
@@ -0,0 +1,122 @@
+package source
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"searchav/internal/config"
+	"searchav/internal/logging"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog"
+)
+
+const (
+	xmlAPIPath = "/provide/vod/at/xml"
+)
+
+// macCMSXML is the adapter for the legacy MacCMS XML API
+type macCMSXML struct {
+	http   *resty.Client
+	logger *zerolog.Logger
+}
+
+// newMacCMSXML creates a MacCMS XML adapter
+func newMacCMSXML(http *resty.Client, logger *zerolog.Logger) *macCMSXML {
+	return &macCMSXML{
+		http:   http,
+		logger: logger,
+	}
+}
+
+// Search searches videos from a source
+func (p *macCMSXML) Search(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
+	logger := logging.FromContext(ctx, p.logger)
+	reqURL := buildURL(src, xmlAPIPath, url.Values{"ac": {"videolist"}, "wd": {keyword}})
+
+	logger.Info().Str("url", reqURL).Str("source", src.Code).Msg("search request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		logger.Error().Err(err).Str("source", src.Code).Msg("search request failed")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("source", src.Code).
+		Int("total", resp.List.RecordCount).
+		Int("count", len(resp.List.Videos)).
+		Msg("search response")
+
+	return resp.rawVideos(), nil
+}
+
+// GetDetail gets video detail from a source
+func (p *macCMSXML) GetDetail(ctx context.Context, src config.SourceItem, vodID int) (*RawVideo, error) {
+	reqURL := buildURL(src, xmlAPIPath, url.Values{"ac": {"videolist"}, "ids": {strconv.Itoa(vodID)}})
+
+	logging.FromContext(ctx, p.logger).Debug().Str("url", reqURL).Str("source", src.Code).Msg("detail request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	list := resp.rawVideos()
+	if len(list) == 0 {
+		return nil, ErrVideoNotFound
+	}
+
+	return &list[0], nil
+}
+
+// ListCategories lists the categories of a source
+func (p *macCMSXML) ListCategories(ctx context.Context, src config.SourceItem) ([]Category, error) {
+	reqURL := buildURL(src, xmlAPIPath, url.Values{"ac": {"list"}})
+
+	logging.FromContext(ctx, p.logger).Debug().Str("url", reqURL).Str("source", src.Code).Msg("category request")
+
+	resp, err := p.fetch(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]Category, 0, len(resp.Class.Types))
+	for _, t := range resp.Class.Types {
+		categories = append(categories, Category{
+			TypeID:   t.ID,
+			TypeName: strings.TrimSpace(t.Name),
+		})
+	}
+	return categories, nil
+}
+
+// fetch requests an XML API URL and decodes the response
+func (p *macCMSXML) fetch(ctx context.Context, reqURL string) (*MacCMSXMLResponse, error) {
+	httpResp, err := p.http.R().
+		SetContext(ctx).
+		Get(reqURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.IsError() {
+		return nil, &StatusError{StatusCode: httpResp.StatusCode()}
+	}
+
+	return DecodeMacCMSXML(httpResp.Body())
+}
+
+// DecodeMacCMSXML decodes a MacCMS XML API response body
+func DecodeMacCMSXML(data []byte) (*MacCMSXMLResponse, error) {
+	var resp MacCMSXMLResponse
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("%w: maccms xml: %v", ErrDecode, err)
+	}
+	return &resp, nil
+}
@@ -1,13 +1,25 @@
 package source
 
+import (
+	"encoding/xml"
+	"strings"
+)
+
 // MacCMSResponse is the MacCMS v10 API response structure
 type MacCMSResponse struct {
 	Code  int        `json:"code"`
 	Msg   string     `json:"msg"`
 	List  []RawVideo `json:"list"`
+	Class []Category `json:"class"`
 	Total int        `json:"total"`
 }
 
+// Category is a video category exposed by a source
+type Category struct {
+	TypeID   int    `json:"type_id"`
+	TypeName string `json:"type_name"`
+}
+
 // RawVideo is the raw video data from API
 type RawVideo struct {
 	VodID       int    `json:"vod_id"`
@@ -15,6 +27,7 @@ type RawVideo struct {
 	VodPic      string `json:"vod_pic"`
 	VodRemarks  string `json:"vod_remarks"`
 	TypeName    string `json:"type_name"`
+	VodPlayFrom string `json:"vod_play_from"`
 	VodPlayURL  string `json:"vod_play_url"`
 	VodContent  string `json:"vod_content"`
 	VodYear     string `json:"vod_year"`
@@ -26,3 +39,83 @@ type RawVideo struct {
 	SourceCode string `json:"-"`
 	SourceName string `json:"-"`
 }
+
+// MacCMSXMLResponse is the legacy MacCMS XML API response structure
+type MacCMSXMLResponse struct {
+	XMLName xml.Name     `xml:"rss"`
+	List    XMLVideoList `xml:"list"`
+	Class   XMLClass     `xml:"class"`
+}
+
+// XMLVideoList is the <list> element of the XML API
+type XMLVideoList struct {
+	Page        int        `xml:"page,attr"`
+	PageCount   int        `xml:"pagecount,attr"`
+	PageSize    int        `xml:"pagesize,attr"`
+	RecordCount int        `xml:"recordcount,attr"`
+	Videos      []XMLVideo `xml:"video"`
+}
+
+// XMLVideo is a <video> element of the XML API
+type XMLVideo struct {
+	ID       int          `xml:"id"`
+	TypeID   int          `xml:"tid"`
+	Name     string       `xml:"name"`
+	Type     string       `xml:"type"`
+	Pic      string       `xml:"pic"`
+	Lang     string       `xml:"lang"`
+	Area     string       `xml:"area"`
+	Year     string       `xml:"year"`
+	Note     string       `xml:"note"`
+	Actor    string       `xml:"actor"`
+	Director string       `xml:"director"`
+	PlayList []XMLPlayURL `xml:"dl>dd"`
+	Des      string       `xml:"des"`
+}
+
+// XMLPlayURL is a <dd flag="..."> play line of the XML API
+type XMLPlayURL struct {
+	Flag string `xml:"flag,attr"`
+	URL  string `xml:",chardata"`
+}
+
+// XMLClass is the <class> element of the XML API
+type XMLClass struct {
+	Types []XMLType `xml:"ty"`
+}
+
+// XMLType is a <ty id="..."> category of the XML API
+type XMLType struct {
+	ID   int    `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+// rawVideos converts XML videos into the same RawVideo values as the JSON API.
+// Play lines are joined with $$$ like vod_play_from and vod_play_url.
+func (r *MacCMSXMLResponse) rawVideos() []RawVideo {
+	list := make([]RawVideo, 0, len(r.List.Videos))
+	for _, v := range r.List.Videos {
+		flags := make([]string, 0, len(v.PlayList))
+		urls := make([]string, 0, len(v.PlayList))
+		for _, dd := range v.PlayList {
+			flags = append(flags, strings.TrimSpace(dd.Flag))
+			urls = append(urls, strings.TrimSpace(dd.URL))
+		}
+
+		list = append(list, RawVideo{
+			VodID:       v.ID,
+			VodName:     strings.TrimSpace(v.Name),
+			VodPic:      strings.TrimSpace(v.Pic),
+			VodRemarks:  strings.TrimSpace(v.Note),
+			TypeName:    strings.TrimSpace(v.Type),
+			VodPlayFrom: strings.Join(flags, "$$$"),
+			VodPlayURL:  strings.Join(urls, "$$$"),
+			VodContent:  strings.TrimSpace(v.Des),
+			VodYear:     strings.TrimSpace(v.Year),
+			VodArea:     strings.TrimSpace(v.Area),
+			VodDirector: strings.TrimSpace(v.Director),
+			VodActor:    strings.TrimSpace(v.Actor),
+		})
+	}
+	return list
+}
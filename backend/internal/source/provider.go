@@ -0,0 +1,41 @@
+package source
+
+import (
+	"context"
+
+	"searchav/internal/config"
+)
+
+// Source protocol types, matched against config.SourceItem.Type
+const (
+	TypeMacCMSJSON = "maccms_json"
+	TypeMacCMSXML  = "maccms_xml"
+
+	// DefaultType is used when a source does not declare a type
+	DefaultType = TypeMacCMSJSON
+)
+
+// Provider is implemented by every source protocol adapter
+type Provider interface {
+	// Search searches videos by keyword
+	Search(ctx context.Context, src config.SourceItem, keyword string) ([]RawVideo, error)
+	// GetDetail gets a single video including its play URLs
+	GetDetail(ctx context.Context, src config.SourceItem, vodID int) (*RawVideo, error)
+	// ListCategories lists the categories exposed by the source
+	ListCategories(ctx context.Context, src config.SourceItem) ([]Category, error)
+}
+
+// KeywordSearcher searches a source for several spellings of one query at
+// once, such as its Simplified and Traditional forms. Videos found by several
+// keywords are returned once, and the search counts as a single request to the
+// source's circuit breaker. It is implemented by Client and CachedProvider,
+// which run one Search of the source's protocol provider per keyword; the
+// protocol providers themselves do not implement it.
+type KeywordSearcher interface {
+	SearchKeywords(ctx context.Context, src config.SourceItem, keywords []string) ([]RawVideo, error)
+}
+
+// Fetcher fetches raw resources such as playlists over the source HTTP client
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) ([]byte, error)
+}
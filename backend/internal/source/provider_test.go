@@ -0,0 +1,172 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"searchav/internal/config"
+)
+
+// fakeProvider answers from fixed videos and records the sources it was asked
+type fakeProvider struct {
+	list  []RawVideo
+	err   error
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeProvider) Search(_ context.Context, src config.SourceItem, keyword string) ([]RawVideo, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, src.Code+":"+keyword)
+	f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	// Callers own the returned slice
+	return append([]RawVideo(nil), f.list...), nil
+}
+
+func (f *fakeProvider) GetDetail(_ context.Context, src config.SourceItem, vodID int) (*RawVideo, error) {
+	f.calls = append(f.calls, src.Code)
+	for _, v := range f.list {
+		if v.VodID == vodID {
+			return &v, nil
+		}
+	}
+	return nil, ErrVideoNotFound
+}
+
+func (f *fakeProvider) ListCategories(_ context.Context, src config.SourceItem) ([]Category, error) {
+	f.calls = append(f.calls, src.Code)
+	return nil, f.err
+}
+
+func TestClientSearchRouting(t *testing.T) {
+	c := newTestClient()
+	jsonFake := &fakeProvider{list: []RawVideo{{VodID: 1, VodName: "json"}}}
+	customFake := &fakeProvider{list: []RawVideo{{VodID: 2, VodName: "custom"}}}
+	c.Register(TypeMacCMSJSON, jsonFake)
+	c.Register("custom", customFake)
+
+	tests := []struct {
+		name     string
+		src      config.SourceItem
+		provider *fakeProvider
+		want     string
+	}{
+		{"default type", config.SourceItem{Code: "a", Name: "A"}, jsonFake, "json"},
+		{"declared type", config.SourceItem{Code: "b", Name: "B", Type: TypeMacCMSJSON}, jsonFake, "json"},
+		{"registered type", config.SourceItem{Code: "c", Name: "C", Type: "custom"}, customFake, "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(tt.provider.calls)
+			list, err := c.Search(context.Background(), tt.src, "kw")
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(tt.provider.calls) != before+1 || tt.provider.calls[before] != tt.src.Code+":kw" {
+				t.Fatalf("provider calls = %v, want one call for %s", tt.provider.calls, tt.src.Code)
+			}
+			if len(list) != 1 || list[0].VodName != tt.want {
+				t.Fatalf("Search = %+v, want %s", list, tt.want)
+			}
+			if list[0].SourceCode != tt.src.Code || list[0].SourceName != tt.src.Name {
+				t.Errorf("source info = %q/%q, want %q/%q", list[0].SourceCode, list[0].SourceName, tt.src.Code, tt.src.Name)
+			}
+		})
+	}
+
+	if _, err := c.Search(context.Background(), config.SourceItem{Code: "d", Type: "unknown"}, "kw"); err == nil {
+		t.Error("Search with an unregistered type succeeded, want an error")
+	}
+	if c.Supports("unknown") || !c.Supports("") || !c.Supports("custom") {
+		t.Error("Supports does not match the registered providers")
+	}
+}
+
+func TestClientSearchBreaker(t *testing.T) {
+	c := newTestClient()
+	c.health = newHealthTracker(config.BreakerConfig{Enabled: true, FailureThreshold: 2, Cooldown: time.Minute})
+	failing := &fakeProvider{err: &StatusError{StatusCode: 500}}
+	c.Register(TypeMacCMSJSON, failing)
+	src := config.SourceItem{Code: "a"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Search(context.Background(), src, "kw"); Classify(err) != ErrorClassHTTPStatus {
+			t.Fatalf("Search %d error = %v, want class %s", i, err, ErrorClassHTTPStatus)
+		}
+	}
+	if _, err := c.Search(context.Background(), src, "kw"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Search after threshold error = %v, want ErrCircuitOpen", err)
+	}
+	if len(failing.calls) != 2 {
+		t.Errorf("provider called %d times, want 2 as the open circuit skips it", len(failing.calls))
+	}
+}
+
+func TestClientSearchKeywords(t *testing.T) {
+	c := newTestClient()
+	fake := &fakeProvider{list: []RawVideo{{VodID: 1, VodName: "a"}, {VodID: 2, VodName: "b"}}}
+	c.Register(TypeMacCMSJSON, fake)
+	src := config.SourceItem{Code: "a", Name: "A"}
+
+	list, err := c.SearchKeywords(context.Background(), src, []string{"庆余年", "慶餘年"})
+	if err != nil {
+		t.Fatalf("SearchKeywords: %v", err)
+	}
+	if len(fake.calls) != 2 {
+		t.Errorf("provider calls = %v, want one per keyword", fake.calls)
+	}
+	if len(list) != 2 || list[0].SourceCode != "a" {
+		t.Errorf("SearchKeywords = %+v, want videos 1 and 2 once with source info", list)
+	}
+	if h := c.Health("a"); h.Requests != 1 {
+		t.Errorf("health requests = %d, want 1 per search", h.Requests)
+	}
+}
+
+// A failing source is penalized once per search, not once per keyword
+func TestClientSearchKeywordsBreaker(t *testing.T) {
+	c := newTestClient()
+	c.health = newHealthTracker(config.BreakerConfig{Enabled: true, FailureThreshold: 2, Cooldown: time.Minute})
+	failing := &fakeProvider{err: &StatusError{StatusCode: 500}}
+	c.Register(TypeMacCMSJSON, failing)
+	src := config.SourceItem{Code: "a"}
+	keywords := []string{"kw1", "kw2", "kw3"}
+
+	if _, err := c.SearchKeywords(context.Background(), src, keywords); Classify(err) != ErrorClassHTTPStatus {
+		t.Fatalf("SearchKeywords error = %v, want class %s", err, ErrorClassHTTPStatus)
+	}
+	if h := c.Health("a"); h.ConsecutiveFailures != 1 || h.State != BreakerClosed {
+		t.Fatalf("health after one search = %d failures, %s, want 1, %s", h.ConsecutiveFailures, h.State, BreakerClosed)
+	}
+
+	_, _ = c.SearchKeywords(context.Background(), src, keywords)
+	if _, err := c.SearchKeywords(context.Background(), src, keywords); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("SearchKeywords after threshold error = %v, want ErrCircuitOpen", err)
+	}
+	if len(failing.calls) != 6 {
+		t.Errorf("provider called %d times, want 6 for two searches", len(failing.calls))
+	}
+}
+
+// One failing keyword does not fail the search
+func TestMergeKeywordResults(t *testing.T) {
+	boom := errors.New("boom")
+	list, err := mergeKeywordResults(
+		[][]RawVideo{{{VodID: 1}}, nil, {{VodID: 1}, {VodID: 2}}},
+		[]error{nil, boom, nil},
+	)
+	if err != nil || len(list) != 2 {
+		t.Errorf("merge = %+v, %v, want videos 1 and 2", list, err)
+	}
+
+	if _, err := mergeKeywordResults([][]RawVideo{nil, nil}, []error{boom, boom}); !errors.Is(err, boom) {
+		t.Errorf("merge of failures error = %v, want boom", err)
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"searchav/internal/config"
+)
+
+// SourceStore persists the source list managed through the admin API
+type SourceStore struct {
+	path string
+}
+
+// NewSourceStore creates a source store backed by the configured file.
+// Persistence is disabled when no file is configured.
+func NewSourceStore(cfg *config.Config) *SourceStore {
+	return &SourceStore{
+		path: cfg.Admin.SourcesStore,
+	}
+}
+
+// Enabled reports whether a store file is configured
+func (s *SourceStore) Enabled() bool {
+	return s.path != ""
+}
+
+// Load reads the stored source list. ok is false when nothing was stored yet.
+func (s *SourceStore) Load() (sources []config.SourceItem, ok bool, err error) {
+	if !s.Enabled() {
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, false, fmt.Errorf("failed to parse sources store %s: %w", s.path, err)
+	}
+	return sources, true, nil
+}
+
+// Save writes the source list, replacing the file atomically
+func (s *SourceStore) Save(sources []config.SourceItem) error {
+	if !s.Enabled() {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".sources-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,79 @@
+package title
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Character tables, see the headers of the files for their origin
+var (
+	//go:embed t2s.txt
+	t2sTable string
+	//go:embed s2t.txt
+	s2tTable string
+)
+
+var (
+	tablesOnce sync.Once
+	t2s        map[rune]rune
+	s2t        map[rune]rune
+)
+
+// ToSimplified converts Traditional Chinese characters to Simplified ones,
+// leaving other characters unchanged. Characters with several Simplified
+// forms, which depend on the word, are left unchanged as well.
+func ToSimplified(s string) string {
+	tablesOnce.Do(loadTables)
+	return convert(s, t2s)
+}
+
+// ToTraditional converts Simplified Chinese characters to Traditional ones,
+// leaving other characters unchanged. Characters with several Traditional
+// forms, such as 发 (發, 髮), are left unchanged as well.
+func ToTraditional(s string) string {
+	tablesOnce.Do(loadTables)
+	return convert(s, s2t)
+}
+
+// ScriptVariants returns s followed by its Simplified and Traditional forms
+// when they differ from it
+func ScriptVariants(s string) []string {
+	variants := []string{s}
+	for _, v := range []string{ToSimplified(s), ToTraditional(s)} {
+		if !slices.Contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+func convert(s string, table map[rune]rune) string {
+	return strings.Map(func(r rune) rune {
+		if c, ok := table[r]; ok {
+			return c
+		}
+		return r
+	}, s)
+}
+
+func loadTables() {
+	t2s = parseTable(t2sTable)
+	s2t = parseTable(s2tTable)
+}
+
+// parseTable parses lines of two characters, skipping # comments
+func parseTable(data string) map[rune]rune {
+	table := make(map[rune]rune, 4096)
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		runes := []rune(line)
+		if len(runes) == 2 {
+			table[runes[0]] = runes[1]
+		}
+	}
+	return table
+}
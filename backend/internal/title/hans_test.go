@@ -0,0 +1,48 @@
+package title
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScriptConversion(t *testing.T) {
+	tests := []struct {
+		in          string
+		simplified  string
+		traditional string
+	}{
+		{"海贼王", "海贼王", "海賊王"},
+		{"慶餘年", "庆余年", "慶餘年"},
+		{"國語", "国语", "國語"},
+		// Characters whose form depends on the word are left alone
+		{"乾隆", "乾隆", "乾隆"},
+		{"皇后", "皇后", "皇后"},
+		{"头发", "头发", "頭发"},
+		{"干杯", "干杯", "干杯"},
+		{"庆余年", "庆余年", "慶余年"},
+		{"故里", "故里", "故里"},
+		{"ONE PIECE 2", "ONE PIECE 2", "ONE PIECE 2"},
+	}
+	for _, tt := range tests {
+		if got := ToSimplified(tt.in); got != tt.simplified {
+			t.Errorf("ToSimplified(%q) = %q, want %q", tt.in, got, tt.simplified)
+		}
+		if got := ToTraditional(tt.in); got != tt.traditional {
+			t.Errorf("ToTraditional(%q) = %q, want %q", tt.in, got, tt.traditional)
+		}
+	}
+}
+
+func TestScriptVariants(t *testing.T) {
+	tests := map[string][]string{
+		"海贼王":    {"海贼王", "海賊王"},
+		"慶餘年":    {"慶餘年", "庆余年"},
+		"皇后":     {"皇后"},
+		"batman": {"batman"},
+	}
+	for in, want := range tests {
+		if got := ScriptVariants(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("ScriptVariants(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package title
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Title is a video title reduced to the work it names
+type Title struct {
+	Key    string   // Normalized title, equal for variants of the same work
+	Season int      // Season number, 0 when not given
+	Labels []string // Language and quality labels stripped from the title, e.g. 国语, HD
+}
+
+// Variant returns the label telling a variant of the work apart, such as
+// "第2季 粤语", empty for the plain title
+func (t Title) Variant() string {
+	parts := make([]string, 0, len(t.Labels)+1)
+	if t.Season > 0 {
+		parts = append(parts, "第"+strconv.Itoa(t.Season)+"季")
+	}
+	parts = append(parts, t.Labels...)
+	return strings.Join(parts, " ")
+}
+
+// labels maps the lowercase language and quality suffixes found in source
+// titles to the label they are reported as
+var labels = map[string]string{
+	"国语": "国语", "国语版": "国语", "普通话": "国语", "普通话版": "国语",
+	"粤语": "粤语", "粤语版": "粤语", "国粤": "国粤双语", "国粤双语": "国粤双语",
+	"英语": "英语", "英语版": "英语", "日语": "日语", "日语版": "日语",
+	"韩语": "韩语", "韩语版": "韩语", "台语": "台语", "台配": "台配",
+	"原声": "原声", "原声版": "原声", "双语": "双语", "双语版": "双语",
+	"中字": "中字", "中文字幕": "中字", "无字": "无字",
+	"hd": "HD", "tc": "TC", "ts": "TS", "bd": "BD", "dvd": "DVD",
+	"4k": "4K", "1080p": "1080P", "720p": "720P",
+	"高清": "高清", "超清": "超清", "蓝光": "蓝光", "高清版": "高清",
+	"抢先版": "抢先版", "完整版": "完整版", "未删减": "未删减", "未删减版": "未删减",
+}
+
+// Season markers: 第2季, Season 2, S02.
+// 第二部 numbers the parts of films as often as seasons (教父第二部), and
+// 剧场版 or 特别篇 name a work apart from the series, so they stay in the key.
+var (
+	seasonCN = regexp.MustCompile(`第([0-9零一二两三四五六七八九十百]+)季`)
+	seasonEN = regexp.MustCompile(`(?:\bseason\s*|\bs)([0-9]{1,2})\b`)
+)
+
+// brackets pairs the opening and closing brackets found around title parts
+var brackets = map[rune]rune{
+	'(': ')', '[': ']', '{': '}',
+	'（': '）', '【': '】', '〔': '〕', '［': '］',
+	'「': '」', '『': '』', '《': '》', '〈': '〉',
+}
+
+// Normalize reduces a source title to its work key, season and labels.
+// Traditional Chinese is converted to Simplified, full-width characters are
+// folded to half-width, letters lowercased, bracketed or trailing language
+// and quality labels and season markers removed, and punctuation and spaces
+// dropped.
+func Normalize(name string) Title {
+	s := fold(name)
+
+	var t Title
+	s = stripBracketed(s, &t)
+
+	if m := seasonCN.FindStringSubmatchIndex(s); m != nil {
+		if n := parseNumber(s[m[2]:m[3]]); n > 0 {
+			t.Season = n
+			s = s[:m[0]] + " " + s[m[1]:]
+		}
+	} else if m := seasonEN.FindStringSubmatchIndex(s); m != nil {
+		n, _ := strconv.Atoi(s[m[2]:m[3]])
+		if n > 0 && m[0] > 0 {
+			t.Season = n
+			s = s[:m[0]] + " " + s[m[1]:]
+		}
+	}
+
+	s = stripTrailingLabels(s, &t)
+
+	t.Key = compact(s)
+	if t.Key == "" {
+		// Nothing but labels, keep the title as is
+		return Title{Key: compact(fold(name))}
+	}
+	return t
+}
+
+// stripBracketed removes bracketed parts made of labels or season markers,
+// and the brackets around any other part
+func stripBracketed(s string, t *Title) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		closing, ok := brackets[runes[i]]
+		if !ok {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		end := -1
+		for j := i + 1; j < len(runes); j++ {
+			if runes[j] == closing {
+				end = j
+				break
+			}
+		}
+		if end < 0 {
+			continue
+		}
+
+		inner := strings.TrimSpace(string(runes[i+1 : end]))
+		if !addLabels(inner, t) {
+			b.WriteString(" " + inner + " ")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// addLabels records a bracketed part when it only holds labels or a season
+// marker, such as "国语/HD" or "第二季"
+func addLabels(part string, t *Title) bool {
+	if m := seasonCN.FindStringSubmatch(part); m != nil && m[0] == part {
+		if n := parseNumber(m[1]); n > 0 {
+			t.Season = n
+			return true
+		}
+	}
+
+	fields := strings.FieldsFunc(part, separator)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, f := range fields {
+		if _, ok := labels[f]; !ok {
+			return false
+		}
+	}
+	for _, f := range fields {
+		t.Labels = appendLabel(t.Labels, labels[f])
+	}
+	return true
+}
+
+// stripTrailingLabels removes labels at the end of a title, whether
+// separated from it ("海贼王 HD") or not ("海贼王国语版")
+func stripTrailingLabels(s string, t *Title) string {
+	var found []string
+	for {
+		s = strings.TrimRightFunc(s, separator)
+		label, rest := trailingLabel(s)
+		if label == "" || strings.TrimFunc(rest, separator) == "" {
+			break
+		}
+		found = append(found, label)
+		s = rest
+	}
+
+	// Labels were found back to front
+	for i := len(found) - 1; i >= 0; i-- {
+		t.Labels = appendLabel(t.Labels, found[i])
+	}
+	return s
+}
+
+// trailingLabel returns the longest label ending s and the text before it.
+// Latin labels must stand apart from the preceding word.
+func trailingLabel(s string) (string, string) {
+	best := ""
+	for suffix := range labels {
+		if len(suffix) <= len(best) || !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		rest := s[:len(s)-len(suffix)]
+		if isLatin(suffix) && rest != "" && !separator(lastRune(rest)) {
+			continue
+		}
+		best = suffix
+	}
+	if best == "" {
+		return "", s
+	}
+	return labels[best], s[:len(s)-len(best)]
+}
+
+// fold converts a title to Simplified Chinese, half-width and lowercase
+func fold(name string) string {
+	return strings.ToLower(ToSimplified(foldWidth(strings.TrimSpace(name))))
+}
+
+// foldWidth converts full-width ASCII variants and the ideographic space to
+// their half-width forms
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xfee0
+		}
+		return r
+	}, s)
+}
+
+// compact drops punctuation, symbols and spaces
+func compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// separator reports whether a rune separates words in a title
+func separator(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isLatin(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	return r[len(r)-1]
+}
+
+func appendLabel(list []string, label string) []string {
+	for _, l := range list {
+		if l == label {
+			return list
+		}
+	}
+	return append(list, label)
+}
+
+// cnDigits are the values of Chinese numeral digits
+var cnDigits = map[rune]int{
+	'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// parseNumber parses a number written in Arabic or Chinese numerals up to 999,
+// returning 0 when it cannot be parsed
+func parseNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	total, digit := 0, 0
+	for _, r := range s {
+		switch r {
+		case '百':
+			total += max(digit, 1) * 100
+			digit = 0
+		case '十':
+			total += max(digit, 1) * 10
+			digit = 0
+		default:
+			d, ok := cnDigits[r]
+			if !ok {
+				return 0
+			}
+			digit = d
+		}
+	}
+	return total + digit
+}
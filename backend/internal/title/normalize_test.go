@@ -0,0 +1,90 @@
+package title
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		key    string
+		season int
+		labels []string
+	}{
+		{"plain", "海贼王", "海贼王", 0, nil},
+		{"language suffix", "海贼王国语版", "海贼王", 0, []string{"国语"}},
+		{"bracketed labels", "海贼王【国语/HD】", "海贼王", 0, []string{"国语", "HD"}},
+		{"trailing labels", "海贼王 粤语 1080P", "海贼王", 0, []string{"粤语", "1080P"}},
+		{"arabic season", "请回答1988 第1季", "请回答1988", 1, nil},
+		{"chinese season", "庆余年第二季", "庆余年", 2, nil},
+		{"bracketed season", "庆余年（第二季）国语", "庆余年", 2, []string{"国语"}},
+		{"english season", "Friends Season 2", "friends", 2, nil},
+		{"short season", "Friends S02", "friends", 2, nil},
+		{"full-width", "ＯＮＥ　ＰＩＥＣＥ　ＨＤ", "onepiece", 0, []string{"HD"}},
+		{"full-width season", "ＦＲＩＥＮＤＳ　Ｓ０３", "friends", 3, nil},
+		{"traditional", "海賊王 國語", "海贼王", 0, []string{"国语"}},
+		{"telesync label", "Dune TS", "dune", 0, []string{"TS"}},
+		{"screener label", "流浪地球2 TC", "流浪地球2", 0, []string{"TC"}},
+
+		// Not labels or seasons
+		{"ts inside a word", "Bats", "bats", 0, nil},
+		{"latin label joined to a word", "Gundamts", "gundamts", 0, nil},
+		{"only a label", "TS", "ts", 0, nil},
+		{"only a season marker", "s01", "s01", 0, nil},
+		{"s inside a word", "Mrs 2", "mrs2", 0, nil},
+		{"film part", "教父第二部", "教父第二部", 0, nil},
+		{"film part with label", "教父第二部 国语", "教父第二部", 0, []string{"国语"}},
+		{"movie", "海贼王剧场版", "海贼王剧场版", 0, nil},
+		{"bracketed movie", "海贼王【剧场版】", "海贼王剧场版", 0, nil},
+		{"special", "名侦探柯南 特别篇 国语", "名侦探柯南特别篇", 0, []string{"国语"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(tt.in)
+			if got.Key != tt.key || got.Season != tt.season || !reflect.DeepEqual(got.Labels, tt.labels) {
+				t.Errorf("Normalize(%q) = %q season %d labels %v, want %q season %d labels %v",
+					tt.in, got.Key, got.Season, got.Labels, tt.key, tt.season, tt.labels)
+			}
+		})
+	}
+}
+
+// Variants of one work share a key, different works do not
+func TestNormalizeGroups(t *testing.T) {
+	same := [][2]string{
+		{"海贼王", "海贼王 国语"},
+		{"庆余年 第二季", "慶餘年第2季"},
+		{"ONE PIECE", "ｏｎｅ　ｐｉｅｃｅ"},
+	}
+	for _, pair := range same {
+		a, b := Normalize(pair[0]), Normalize(pair[1])
+		if a.Key != b.Key || a.Season != b.Season {
+			t.Errorf("%q and %q normalize apart: %+v, %+v", pair[0], pair[1], a, b)
+		}
+	}
+
+	apart := [][2]string{
+		{"海贼王", "海贼王剧场版"},
+		{"名侦探柯南", "名侦探柯南特别篇"},
+		{"教父", "教父第二部"},
+	}
+	for _, pair := range apart {
+		if a, b := Normalize(pair[0]), Normalize(pair[1]); a.Key == b.Key {
+			t.Errorf("%q and %q share key %q", pair[0], pair[1], a.Key)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := map[string]int{
+		"2": 2, "12": 12, "二": 2, "两": 2, "十": 10, "十二": 12,
+		"二十": 20, "二十三": 23, "一百零五": 105, "季": 0,
+	}
+	for in, want := range tests {
+		if got := parseNumber(in); got != want {
+			t.Errorf("parseNumber(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
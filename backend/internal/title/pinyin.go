@@ -0,0 +1,312 @@
+package title
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// pinyinArgs romanizes Han characters without tones, keeping other
+// characters as they are
+var pinyinArgs = pinyin.Args{
+	Style: pinyin.Normal,
+	Fallback: func(r rune, _ pinyin.Args) []string {
+		return []string{string(r)}
+	},
+}
+
+// Romanize returns the toneless pinyin of a title and its initials, such as
+// "jinjidejuren" and "jjdjr" for 进击的巨人. Characters with several readings
+// use the most common one, other characters are kept lowercased.
+func Romanize(name string) (full, initials string) {
+	var f, i strings.Builder
+	for _, syllable := range pinyin.LazyPinyin(compact(fold(name)), pinyinArgs) {
+		f.WriteString(syllable)
+		r := []rune(syllable)
+		i.WriteRune(r[0])
+	}
+	return f.String(), i.String()
+}
+
+// IsPinyinQuery reports whether a query may be pinyin or pinyin initials:
+// at least two Latin letters, optionally separated by spaces or apostrophes,
+// that are initials ("jjdjr") or split into at least
+// two pinyin syllables ("jinjide juren"). The last syllable may be
+// incomplete, as the query may be a prefix. English words such as
+// "iron man" and "thegodfather" and codes such as "s01" are not, nor are
+// queries made only of English words that split into syllables, such as
+// "dune" and "china".
+func IsPinyinQuery(query string) bool {
+	letters := 0
+	for _, r := range query {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letters++
+		case r == ' ', r == '\'':
+		default:
+			return false
+		}
+	}
+	if letters < 2 {
+		return false
+	}
+
+	parts := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool { return r == ' ' || r == '\'' })
+	if isInitials(strings.Join(parts, "")) {
+		return true
+	}
+
+	english := true
+	syllables := 0
+	for i, part := range parts {
+		n := countSyllables(part, i == len(parts)-1)
+		if n < 0 {
+			return false
+		}
+		syllables += n
+		english = english && englishWords[part]
+	}
+	return syllables >= 2 && !english
+}
+
+// isInitials reports whether s is made of letters that can start a pinyin
+// syllable with at most one vowel, as initials rarely are while English words
+// mostly have several, and does not spell out syllables ("ban")
+func isInitials(s string) bool {
+	vowels := 0
+	for _, r := range s {
+		if !strings.ContainsRune(pinyinInitials, r) {
+			return false
+		}
+		if r == 'a' || r == 'o' || r == 'e' {
+			vowels++
+		}
+	}
+	return vowels <= 1 && countSyllables(s, false) < 0
+}
+
+// countSyllables returns the most complete pinyin syllables s splits into,
+// -1 if it does not split. When prefix is set, s may end in an incomplete
+// syllable, which is not counted.
+func countSyllables(s string, prefix bool) int {
+	// best[i] is the most syllables s[:i] splits into, -1 if it does not
+	best := make([]int, len(s)+1)
+	for i := range best {
+		best[i] = -1
+	}
+	best[0] = 0
+
+	result := -1
+	for i := 0; i < len(s); i++ {
+		if best[i] < 0 {
+			continue
+		}
+		for j := i + 1; j <= len(s) && j-i <= maxSyllableLen; j++ {
+			if pinyinSyllables[s[i:j]] {
+				best[j] = max(best[j], best[i]+1)
+			}
+		}
+		if prefix && syllablePrefixes[s[i:]] {
+			result = max(result, best[i])
+		}
+	}
+	return max(result, best[len(s)])
+}
+
+// hasHan reports whether a string contains Han characters
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pinyin match ranks, higher is better
+const (
+	matchNone = iota
+	matchInitialsPrefix
+	matchFullPrefix
+	matchExact
+)
+
+// Origin is where a title was seen: a source and the category it lists the
+// title in
+type Origin struct {
+	Source   string
+	Category string
+}
+
+// pinyinEntry is an indexed title
+type pinyinEntry struct {
+	name     string // Title searched for when the entry matches
+	full     string
+	initials string
+	variant  bool // name is a labelled variant, replaced by the plain title when seen
+	seen     time.Time
+	origins  map[Origin]bool
+}
+
+// PinyinIndex maps pinyin and pinyin initials to the Chinese titles seen in
+// search results, so titles can be found by typing "jjdjr" or "jinjidejuren".
+// It keeps the most recently seen titles up to a maximum, with the sources
+// and categories they were seen in, so lookups only return titles the caller
+// may see.
+type PinyinIndex struct {
+	mu        sync.RWMutex
+	entries   map[string]*pinyinEntry // By normalized title
+	maxTitles int
+	now       func() time.Time
+}
+
+// NewPinyinIndex creates an index holding up to maxTitles titles
+func NewPinyinIndex(maxTitles int) *PinyinIndex {
+	return &PinyinIndex{
+		entries:   make(map[string]*pinyinEntry),
+		maxTitles: maxTitles,
+		now:       time.Now,
+	}
+}
+
+// Add indexes a title seen in the given origins. Variants of a title share
+// one entry, searched for by the plain title once it has been seen. Titles
+// without Han characters are ignored.
+func (x *PinyinIndex) Add(name string, origins ...Origin) {
+	name = strings.TrimSpace(name)
+	t := Normalize(name)
+	if !hasHan(t.Key) {
+		return
+	}
+	variant := t.Variant() != ""
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := x.now()
+	e, ok := x.entries[t.Key]
+	if !ok {
+		full, initials := Romanize(t.Key)
+		e = &pinyinEntry{
+			name:     name,
+			full:     full,
+			initials: initials,
+			variant:  variant,
+			origins:  make(map[Origin]bool, len(origins)),
+		}
+		x.entries[t.Key] = e
+	} else if e.variant && !variant {
+		e.name = name
+		e.variant = false
+	}
+	e.seen = now
+	for _, o := range origins {
+		e.origins[o] = true
+	}
+
+	if len(x.entries) > x.maxTitles {
+		x.evict()
+	}
+}
+
+// Lookup returns up to limit titles whose pinyin or initials match the query,
+// best matches first: exact matches, then full pinyin prefixes, then initials
+// prefixes, shorter titles first within a rank. Only titles seen in an origin
+// allow accepts are returned.
+func (x *PinyinIndex) Lookup(query string, limit int, allow func(Origin) bool) []string {
+	q := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\'' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, query)
+	if q == "" || limit <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		name string
+		rank int
+		size int
+	}
+
+	x.mu.RLock()
+	var found []candidate
+	for _, e := range x.entries {
+		if rank := e.match(q); rank != matchNone && e.allowed(allow) {
+			found = append(found, candidate{name: e.name, rank: rank, size: len(e.initials)})
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].rank != found[j].rank {
+			return found[i].rank > found[j].rank
+		}
+		if found[i].size != found[j].size {
+			return found[i].size < found[j].size
+		}
+		return found[i].name < found[j].name
+	})
+
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	names := make([]string, 0, len(found))
+	for _, c := range found {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Len returns the number of indexed titles
+func (x *PinyinIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// match ranks how an entry matches a lowercase query
+func (e *pinyinEntry) match(q string) int {
+	switch {
+	case e.initials == q, e.full == q:
+		return matchExact
+	case strings.HasPrefix(e.full, q):
+		return matchFullPrefix
+	case strings.HasPrefix(e.initials, q):
+		return matchInitialsPrefix
+	default:
+		return matchNone
+	}
+}
+
+// allowed reports whether the entry was seen in an origin allow accepts
+func (e *pinyinEntry) allowed(allow func(Origin) bool) bool {
+	for o := range e.origins {
+		if allow(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// evict drops the least recently seen tenth of the titles beyond the maximum,
+// so evictions are batched. Callers must hold the lock.
+func (x *PinyinIndex) evict() {
+	keys := make([]string, 0, len(x.entries))
+	for key := range x.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return x.entries[keys[i]].seen.Before(x.entries[keys[j]].seen)
+	})
+
+	drop := len(keys) - x.maxTitles + x.maxTitles/10
+	for _, key := range keys[:min(drop, len(keys))] {
+		delete(x.entries, key)
+	}
+}
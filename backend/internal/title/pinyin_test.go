@@ -0,0 +1,87 @@
+package title
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsPinyinQuery(t *testing.T) {
+	tests := map[string]bool{
+		"jinjidejuren":   true,
+		"jinjide juren":  true,
+		"xi'an":          true,
+		"jjdjr":          true,
+		"JJDJR":          true,
+		"zhuangzhuang":   true,
+		"jinjidej":       true, // Typing the next syllable
+		"haizeiwa":       true,
+		"iron man":       false,
+		"ironman":        false,
+		"s01":            false,
+		"friends s01":    false,
+		"avengers":       false,
+		"q":              false,
+		"进击的巨人":          false,
+		"jinji de juren": true,
+		"jinjide uren":   false,
+		"xian":           true,
+		"tang shan":      true,
+		"dune":           false, // du ne
+		"china":          false, // chi na
+		"Dune":           false,
+		"thegodfather":   false, // All letters can start a syllable
+		"the godfather":  false,
+		"human":          false,
+		"lion king":      false,
+		"long":           false, // A single syllable
+		"ban":            false,
+		"aqgy":           true, // Initials of a title starting with 爱
+	}
+	for query, want := range tests {
+		if got := IsPinyinQuery(query); got != want {
+			t.Errorf("IsPinyinQuery(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestRomanize(t *testing.T) {
+	full, initials := Romanize("进击的巨人")
+	if full != "jinjidejuren" || initials != "jjdjr" {
+		t.Errorf("Romanize = %q, %q, want jinjidejuren, jjdjr", full, initials)
+	}
+}
+
+func TestPinyinIndexLookup(t *testing.T) {
+	x := NewPinyinIndex(100)
+	x.Add("进击的巨人 第2季", Origin{Source: "pub", Category: "动漫"})
+	x.Add("进击的巨人", Origin{Source: "pub", Category: "动漫"})
+	x.Add("进击的巨人真人版", Origin{Source: "pub", Category: "电影"})
+	x.Add("禁忌的巨人", Origin{Source: "adult", Category: "伦理"})
+	x.Add("Attack on Titan", Origin{Source: "pub", Category: "动漫"})
+
+	all := func(Origin) bool { return true }
+	pub := func(o Origin) bool { return o.Source == "pub" }
+	anime := func(o Origin) bool { return o.Source == "pub" && o.Category == "动漫" }
+
+	tests := []struct {
+		name  string
+		query string
+		allow func(Origin) bool
+		want  []string
+	}{
+		{"exact initials", "jjdjr", all, []string{"禁忌的巨人", "进击的巨人", "进击的巨人真人版"}},
+		{"full prefix", "jinjide", pub, []string{"进击的巨人", "进击的巨人真人版"}},
+		{"other sources hidden", "jjdjr", pub, []string{"进击的巨人", "进击的巨人真人版"}},
+		{"exact full", "jinjidejuren", pub, []string{"进击的巨人", "进击的巨人真人版"}},
+		{"homophone from allowed source", "jinjidejuren", func(o Origin) bool { return o.Source == "adult" }, []string{"禁忌的巨人"}},
+		{"categories hidden", "jjdjr", anime, []string{"进击的巨人"}},
+		{"nothing allowed", "jjdjr", func(Origin) bool { return false }, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := x.Lookup(tt.query, 5, tt.allow); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package title
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// pinyinInitials are the letters pinyin syllables can start with: the
+// consonant initials and the vowels of syllables without one
+const pinyinInitials = "bpmfdtnlgkhjqxzcsrywaoe"
+
+// maxSyllableLen is the length of the longest pinyin syllable
+const maxSyllableLen = 6
+
+// syllableList holds the toneless pinyin syllables of Mandarin, with v
+// standing for ü
+const syllableList = `
+a ai an ang ao
+ba bai ban bang bao bei ben beng bi bian biao bie bin bing bo bu
+ca cai can cang cao ce cen ceng cha chai chan chang chao che chen cheng chi
+chong chou chu chua chuai chuan chuang chui chun chuo ci cong cou cu cuan cui
+cun cuo
+da dai dan dang dao de dei den deng di dia dian diao die ding diu dong dou du
+duan dui dun duo
+e ei en eng er
+fa fan fang fei fen feng fo fou fu
+ga gai gan gang gao ge gei gen geng gong gou gu gua guai guan guang gui gun guo
+ha hai han hang hao he hei hen heng hong hou hu hua huai huan huang hui hun huo
+ji jia jian jiang jiao jie jin jing jiong jiu ju juan jue jun
+ka kai kan kang kao ke kei ken keng kong kou ku kua kuai kuan kuang kui kun kuo
+la lai lan lang lao le lei leng li lia lian liang liao lie lin ling liu lo long
+lou lu luan lun luo lv lve
+ma mai man mang mao me mei men meng mi mian miao mie min ming miu mo mou mu
+na nai nan nang nao ne nei nen neng ni nian niang niao nie nin ning niu nong
+nou nu nuan nun nuo nv nve
+o ou
+pa pai pan pang pao pei pen peng pi pian piao pie pin ping po pou pu
+qi qia qian qiang qiao qie qin qing qiong qiu qu quan que qun
+ran rang rao re ren reng ri rong rou ru rua ruan rui run ruo
+sa sai san sang sao se sen seng sha shai shan shang shao she shei shen sheng shi
+shou shu shua shuai shuan shuang shui shun shuo si song sou su suan sui sun suo
+ta tai tan tang tao te teng ti tian tiao tie ting tong tou tu tuan tui tun tuo
+wa wai wan wang wei wen weng wo wu
+xi xia xian xiang xiao xie xin xing xiong xiu xu xuan xue xun
+ya yan yang yao ye yi yin ying yo yong you yu yuan yue yun
+za zai zan zang zao ze zei zen zeng zha zhai zhan zhang zhao zhe zhei zhen zheng
+zhi zhong zhou zhu zhua zhuai zhuan zhuang zhui zhun zhuo zi zong zou zu zuan
+zui zun zuo
+`
+
+// pinyinSyllables and syllablePrefixes index the syllables and every
+// non-empty prefix of them
+var pinyinSyllables, syllablePrefixes = indexSyllables()
+
+func indexSyllables() (map[string]bool, map[string]bool) {
+	syllables := make(map[string]bool, 420)
+	prefixes := make(map[string]bool, 1024)
+	for _, s := range strings.Fields(syllableList) {
+		syllables[s] = true
+		for i := 1; i <= len(s); i++ {
+			prefixes[s[:i]] = true
+		}
+	}
+	return syllables, prefixes
+}
+
+// englishList holds English words that also split into pinyin syllables
+//
+//go:embed english.txt
+var englishList string
+
+// englishWords indexes englishList
+var englishWords = indexWords(englishList)
+
+// indexWords indexes the words of a list of lines, skipping # comments
+func indexWords(list string) map[string]bool {
+	words := make(map[string]bool, 256)
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); line != "" && line[0] != '#' {
+			words[line] = true
+		}
+	}
+	return words
+}
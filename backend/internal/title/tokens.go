@@ -0,0 +1,51 @@
+package title
+
+import (
+	"unicode"
+)
+
+// Tokens splits a title into words for matching: runs of Latin letters and
+// digits become one token each, runs of Han characters become overlapping
+// character pairs, as Chinese titles are not separated into words. Text is
+// folded like Normalize does.
+func Tokens(name string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+		switch {
+		case len(han) == 1:
+			tokens = append(tokens, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range fold(name) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
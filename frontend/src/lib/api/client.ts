@@ -1,14 +1,23 @@
-import type { VideoResult, VideoDetail, SearchResponse, DetailResponse } from '$lib/types';
+import type {
+	VideoResult,
+	VideoDetail,
+	SearchResponse,
+	SearchFilter,
+	DetailResponse,
+	SourceListItem,
+	SourcesResponse,
+	LoginResponse
+} from '$lib/types';
 import { browser } from '$app/environment';
 import { PUBLIC_API_HOST } from '$env/static/public';
 
 const API_BASE = PUBLIC_API_HOST ? `${PUBLIC_API_HOST}/api` : '/api';
-const AUTH_HEADER = 'X-Auth-Password';
-const STORAGE_KEY = 'searchav_auth_password';
+const STORAGE_KEY = 'searchav_auth_token';
+const LEGACY_PASSWORD_KEY = 'searchav_auth_password';
 const ADULT_MODE_KEY = 'searchav_adult_mode';
 
-/** Get stored password */
-function getPassword(): string | null {
+/** Get stored session token */
+function getToken(): string | null {
 	if (browser) {
 		return localStorage.getItem(STORAGE_KEY);
 	}
@@ -18,9 +27,9 @@ function getPassword(): string | null {
 /** Create headers with auth */
 function createHeaders(): HeadersInit {
 	const headers: HeadersInit = {};
-	const password = getPassword();
-	if (password) {
-		headers[AUTH_HEADER] = password;
+	const token = getToken();
+	if (token) {
+		headers['Authorization'] = `Bearer ${token}`;
 	}
 	return headers;
 }
@@ -31,12 +40,25 @@ function isUnauthorized(res: Response, data: { code: number }): boolean {
 }
 
 /** Search videos */
-export async function search(query: string, includeAdult?: boolean): Promise<VideoResult[]> {
+export async function search(
+	query: string,
+	includeAdult?: boolean,
+	sources?: string[],
+	filter?: SearchFilter
+): Promise<VideoResult[]> {
 	const adult = includeAdult ?? getAdultMode();
 	const params = new URLSearchParams({ q: query });
 	if (adult) {
 		params.set('adult', '1');
 	}
+	if (sources && sources.length > 0) {
+		params.set('sources', sources.join(','));
+	}
+	for (const [key, value] of Object.entries(filter ?? {})) {
+		if (value) {
+			params.set(key, value);
+		}
+	}
 
 	const res = await fetch(`${API_BASE}/search?${params.toString()}`, {
 		headers: createHeaders()
@@ -74,6 +96,25 @@ export async function getDetail(sourceCode: string, vodId: number): Promise<Vide
 	return data.data;
 }
 
+/** List configured sources */
+export async function getSources(): Promise<SourceListItem[]> {
+	const res = await fetch(`${API_BASE}/sources`, {
+		headers: createHeaders()
+	});
+
+	const data: SourcesResponse = await res.json();
+
+	if (isUnauthorized(res, data)) {
+		throw new AuthError('Unauthorized');
+	}
+
+	if (!res.ok || data.code !== 200) {
+		throw new Error(data.msg || 'Failed to get sources');
+	}
+
+	return data.list || [];
+}
+
 /** Auth error class */
 export class AuthError extends Error {
 	constructor(message: string) {
@@ -82,23 +123,41 @@ export class AuthError extends Error {
 	}
 }
 
-/** Save password to storage */
-export function savePassword(password: string) {
+/** Exchange a password for a session token and save the token to storage */
+export async function login(password: string): Promise<void> {
+	const res = await fetch(`${API_BASE}/auth/login`, {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify({ password })
+	});
+
+	const data: LoginResponse = await res.json();
+
+	if (isUnauthorized(res, data)) {
+		throw new AuthError('Invalid password');
+	}
+
+	if (!res.ok || data.code !== 200) {
+		throw new Error(data.msg || 'Login failed');
+	}
+
 	if (browser) {
-		localStorage.setItem(STORAGE_KEY, password);
+		localStorage.setItem(STORAGE_KEY, data.data.token);
+		localStorage.removeItem(LEGACY_PASSWORD_KEY);
 	}
 }
 
-/** Clear password from storage */
+/** Clear session token from storage */
 export function clearPassword() {
 	if (browser) {
 		localStorage.removeItem(STORAGE_KEY);
+		localStorage.removeItem(LEGACY_PASSWORD_KEY);
 	}
 }
 
-/** Check if password exists */
+/** Check if a session token exists */
 export function hasPassword(): boolean {
-	return !!getPassword();
+	return !!getToken();
 }
 
 /** Get adult mode setting */
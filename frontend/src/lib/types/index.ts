@@ -3,6 +3,10 @@ export interface SourceInfo {
 	source_code: string;
 	source_name: string;
 	vod_id: number;
+	/** Title as listed by the source */
+	vod_name?: string;
+	/** Variant label such as 国语 or 第2季, absent for the plain title */
+	variant?: string;
 }
 
 /** Video item in search results */
@@ -11,9 +15,35 @@ export interface VideoResult {
 	vod_pic: string;
 	vod_remarks?: string;
 	type_name?: string;
+	/** Coarse kind shared across source categories */
+	kind?: 'movie' | 'series' | 'anime' | 'variety' | 'documentary';
+	/** Release year such as 2023 */
+	vod_year?: string;
+	vod_area?: string;
 	sources: SourceInfo[];
 }
 
+/** Search result filter, empty fields match everything */
+export interface SearchFilter {
+	/** Kind or exact category name */
+	type?: string;
+	year?: string;
+	area?: string;
+}
+
+/** Number of results with an attribute value */
+export interface FacetCount {
+	value: string;
+	count: number;
+}
+
+/** Result counts by attribute, each ignoring the filter on that attribute */
+export interface SearchFacets {
+	type: FacetCount[];
+	year: FacetCount[];
+	area: FacetCount[];
+}
+
 /** Video detail */
 export interface VideoDetail {
 	vod_name: string;
@@ -23,19 +53,50 @@ export interface VideoDetail {
 	vod_area?: string;
 	vod_director?: string;
 	vod_actor?: string;
+	lines: PlayLine[];
+	/** @deprecated Flat URL list of the preferred line, use lines */
 	episodes: string[];
 }
 
+/** Play line (playback source) of a video */
+export interface PlayLine {
+	name: string;
+	format: 'm3u8' | 'mp4' | 'share';
+	episodes: Episode[];
+}
+
+/** Episode of a play line */
+export interface Episode {
+	title: string;
+	url: string;
+}
+
+/** Per-source status of a search */
+export interface SourceStatus {
+	source_code: string;
+	source_name: string;
+	status: 'ok' | 'empty' | 'timeout' | 'http_error' | 'decode_error' | 'error';
+	count: number;
+	latency_ms: number;
+	error?: string;
+}
+
 /** Search API response */
 export interface SearchResponse {
 	code: number;
+	/** Stable error identifier, set on failures */
+	error?: string;
 	msg?: string;
 	list: VideoResult[];
+	sources?: SourceStatus[];
+	facets?: SearchFacets;
 }
 
 /** Detail API response */
 export interface DetailResponse {
 	code: number;
+	/** Stable error identifier, set on failures */
+	error?: string;
 	msg?: string;
 	data: VideoDetail;
 }
@@ -44,11 +105,34 @@ export interface DetailResponse {
 export interface SourceListItem {
 	code: string;
 	name: string;
+	adult: boolean;
+	enabled: boolean;
+	/** Circuit breaker state */
+	status: 'closed' | 'open' | 'half_open';
+	avg_latency_ms: number;
 }
 
 /** Sources API response */
 export interface SourcesResponse {
 	code: number;
+	/** Stable error identifier, set on failures */
+	error?: string;
 	msg?: string;
 	list: SourceListItem[];
 }
+
+export interface LoginResult {
+	token: string;
+	expires_at: string;
+	profile?: string;
+	adult: boolean;
+	admin: boolean;
+}
+
+export interface LoginResponse {
+	code: number;
+	/** Stable error identifier, set on failures */
+	error?: string;
+	msg?: string;
+	data: LoginResult;
+}